@@ -0,0 +1,168 @@
+package ai
+
+import (
+	"aslm/booth"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// MinConfidence is the lowest confidence at which an extracted product is accepted
+const MinConfidence = 0.5
+
+var (
+	// ErrNoMatch is returned when the model could not identify a product in the input
+	ErrNoMatch = errors.New("no matching Booth product found")
+	// ErrLowConfidence is returned when the identified product is probably wrong
+	ErrLowConfidence = errors.New("Booth product match has low confidence")
+	// ErrInvalidResult is returned when the response fails validation (e.g. a non-Booth URL)
+	ErrInvalidResult = errors.New("invalid product info from AI")
+)
+
+// BoothProductInfo represents extracted Booth product information
+type BoothProductInfo struct {
+	ProductURL string  `json:"productUrl"`
+	ImageURL   string  `json:"imageUrl"`
+	ShopName   string  `json:"shopName"`
+	Confidence float64 `json:"confidence"` // 0-1, how sure the model is that this is the right product
+}
+
+var (
+	productURLPattern = regexp.MustCompile(`^https://([a-z0-9-]+\.)?booth\.pm/([a-z]{2}/)?items/\d+$`)
+	imageURLPattern   = regexp.MustCompile(`^https://booth\.pximg\.net/[a-zA-Z0-9/._-]+\.(?:jpg|jpeg|png|webp|gif)$`)
+)
+
+var productMatchSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"found":      map[string]any{"type": "boolean", "description": "Whether one of the candidates is the product"},
+		"itemId":     map[string]any{"type": "string", "description": "ID of the matching candidate, or empty"},
+		"confidence": map[string]any{"type": "number", "description": "0 to 1"},
+	},
+	"required": []string{"found", "itemId", "confidence"},
+}
+
+// ExtractBoothProductInfo asks the model which Booth search result matches
+// the folder name query. Only the candidate cards (not the page HTML) are
+// sent, trimmed to fit maxTokens; URLs and images come from the chosen card,
+// never from the model. An unidentified product is reported as ErrNoMatch
+// or ErrLowConfidence.
+func ExtractBoothProductInfo(ctx context.Context, p Provider, query string, cards []booth.ItemCard, maxTokens int) (*BoothProductInfo, error) {
+	if len(cards) == 0 {
+		return nil, ErrNoMatch
+	}
+
+	prompt, sent := candidatePrompt(query, cards, maxTokens)
+
+	resp, err := p.Generate(ctx, Request{Prompt: prompt, Schema: productMatchSchema, Temperature: 0.1})
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Found      bool    `json:"found"`
+		ItemID     string  `json:"itemId"`
+		Confidence float64 `json:"confidence"`
+	}
+	if err := json.Unmarshal([]byte(resp.Text), &result); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidResult, err)
+	}
+	if !result.Found || result.ItemID == "" {
+		return nil, ErrNoMatch
+	}
+
+	var card *booth.ItemCard
+	for i := range cards[:sent] {
+		if cards[i].ID == strings.TrimSpace(result.ItemID) {
+			card = &cards[i]
+			break
+		}
+	}
+	if card == nil {
+		return nil, fmt.Errorf("%w: item %q is not one of the candidates", ErrInvalidResult, result.ItemID)
+	}
+
+	info := BoothProductInfo{
+		ProductURL: card.URL,
+		ImageURL:   card.ImageURL,
+		ShopName:   card.Shop,
+		Confidence: result.Confidence,
+	}
+	if info.ShopName == "" {
+		info.ShopName = card.ShopID
+	}
+	if err := validate(&info); err != nil {
+		return nil, err
+	}
+	if info.Confidence < MinConfidence {
+		return &info, fmt.Errorf("%w: %.2f", ErrLowConfidence, info.Confidence)
+	}
+
+	return &info, nil
+}
+
+// candidatePrompt lists as many cards as fit in maxTokens (at least one)
+// and returns the prompt and the number of cards included
+func candidatePrompt(query string, cards []booth.ItemCard, maxTokens int) (string, int) {
+	if maxTokens <= 0 {
+		maxTokens = DefaultMaxInputTokens
+	}
+
+	var b strings.Builder
+	b.WriteString(`You match a downloaded VRChat asset to its Booth.pm product page.
+The folder name of the download is given below, followed by Booth search results.
+
+Rules:
+- Pick the candidate that is the same product as the folder name, comparing titles and shops.
+- If none of them is clearly the same product, set found to false and itemId to "".
+- confidence: how sure you are that the chosen candidate is the product (0 to 1).
+
+Folder name: ` + query + `
+
+Candidates (id | title | shop):
+`)
+
+	sent := 0
+	for _, card := range cards {
+		line := card.ID + " | " + card.Title + " | " + card.Shop + "\n"
+		if sent > 0 && EstimateTokens(b.String()+line) > maxTokens {
+			break
+		}
+		b.WriteString(line)
+		sent++
+	}
+	return b.String(), sent
+}
+
+// validate checks the URLs and fills the shop name from the subdomain if needed
+func validate(info *BoothProductInfo) error {
+	info.ProductURL = strings.TrimSpace(info.ProductURL)
+	info.ImageURL = strings.TrimSpace(info.ImageURL)
+	info.ShopName = strings.TrimSpace(info.ShopName)
+
+	if !productURLPattern.MatchString(info.ProductURL) {
+		return fmt.Errorf("%w: product URL is not a Booth item: %q", ErrInvalidResult, info.ProductURL)
+	}
+	if info.ImageURL != "" && !imageURLPattern.MatchString(info.ImageURL) {
+		return fmt.Errorf("%w: image URL is not on booth.pximg.net: %q", ErrInvalidResult, info.ImageURL)
+	}
+	if info.Confidence < 0 || info.Confidence > 1 {
+		return fmt.Errorf("%w: confidence out of range: %v", ErrInvalidResult, info.Confidence)
+	}
+
+	// shop.booth.pm URLs carry the shop ID, which is better than nothing
+	if info.ShopName == "" {
+		if u, err := url.Parse(info.ProductURL); err == nil {
+			parts := strings.Split(u.Hostname(), ".")
+			if len(parts) >= 3 {
+				info.ShopName = parts[0]
+			}
+		}
+	}
+
+	return nil
+}
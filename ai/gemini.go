@@ -0,0 +1,104 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/genai"
+)
+
+// Gemini is a Provider backed by the Gemini API
+type Gemini struct {
+	APIKey    string
+	Model     string // Defaults to DefaultGeminiModel
+	Embedding string // Embedding model; defaults to DefaultGeminiEmbeddingModel
+}
+
+func (g *Gemini) model() string {
+	if g.Model == "" {
+		return DefaultGeminiModel
+	}
+	return g.Model
+}
+
+// Name implements Provider
+func (g *Gemini) Name() string {
+	return ProviderGemini + "/" + g.model()
+}
+
+// Generate implements Provider
+func (g *Gemini) Generate(ctx context.Context, req Request) (*Response, error) {
+	client, err := genai.NewClient(ctx, &genai.ClientConfig{
+		APIKey: g.APIKey,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Gemini client: %w", err)
+	}
+
+	temperature := req.Temperature
+	config := &genai.GenerateContentConfig{
+		Temperature: &temperature,
+	}
+	if req.Schema != nil {
+		config.ResponseMIMEType = "application/json"
+		config.ResponseJsonSchema = req.Schema
+	}
+
+	resp, err := client.Models.GenerateContent(ctx, g.model(), genai.Text(req.Prompt), config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate content: %w", err)
+	}
+
+	result := &Response{Text: resp.Text()}
+	if resp.UsageMetadata != nil {
+		// Thinking models bill their thoughts as output
+		result.Usage = Usage{
+			InputTokens:  int(resp.UsageMetadata.PromptTokenCount),
+			OutputTokens: int(resp.UsageMetadata.CandidatesTokenCount + resp.UsageMetadata.ThoughtsTokenCount),
+		}
+	}
+	return result, nil
+}
+
+// EmbeddingModel implements Embedder
+func (g *Gemini) EmbeddingModel() string {
+	if g.Embedding == "" {
+		return ProviderGemini + "/" + DefaultGeminiEmbeddingModel
+	}
+	return ProviderGemini + "/" + g.Embedding
+}
+
+// Embed implements Embedder
+func (g *Gemini) Embed(ctx context.Context, texts []string) ([][]float32, Usage, error) {
+	client, err := genai.NewClient(ctx, &genai.ClientConfig{
+		APIKey: g.APIKey,
+	})
+	if err != nil {
+		return nil, Usage{}, fmt.Errorf("failed to create Gemini client: %w", err)
+	}
+
+	model := g.Embedding
+	if model == "" {
+		model = DefaultGeminiEmbeddingModel
+	}
+
+	contents := make([]*genai.Content, len(texts))
+	for i, text := range texts {
+		contents[i] = genai.NewContentFromText(text, genai.RoleUser)
+	}
+
+	resp, err := client.Models.EmbedContent(ctx, model, contents, nil)
+	if err != nil {
+		return nil, Usage{}, fmt.Errorf("failed to embed: %w", err)
+	}
+	if len(resp.Embeddings) != len(texts) {
+		return nil, Usage{}, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(resp.Embeddings))
+	}
+
+	vectors := make([][]float32, len(texts))
+	for i, e := range resp.Embeddings {
+		vectors[i] = e.Values
+	}
+	// The Gemini API doesn't report embedding usage
+	return vectors, Usage{}, nil
+}
@@ -0,0 +1,127 @@
+package ai
+
+import (
+	"aslm/db"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrBudgetExceeded is returned instead of sending a request once today's budget is used up
+var ErrBudgetExceeded = errors.New("daily AI budget exceeded")
+
+// Task names recorded with each request
+const (
+	TaskBoothLookup = "booth-lookup"
+	TaskTagSuggest  = "tag-suggest"
+	TaskEmbedding   = "embedding"
+)
+
+// Budget limits AI use per (local) day. Zero means unlimited.
+type Budget struct {
+	DailyTokens   int
+	DailyRequests int
+}
+
+// Metered wraps a Provider, refusing requests over budget and recording
+// every request it sends in the ai_usage table
+type Metered struct {
+	Provider
+	Task   string
+	Budget Budget
+}
+
+// CheckBudget returns ErrBudgetExceeded if today's usage has reached the budget
+func (m *Metered) CheckBudget() error {
+	if m.Budget.DailyTokens <= 0 && m.Budget.DailyRequests <= 0 {
+		return nil
+	}
+
+	requests, tokens, err := db.AIUsageToday()
+	if err != nil {
+		return fmt.Errorf("failed to read AI usage: %w", err)
+	}
+	if m.Budget.DailyRequests > 0 && requests >= m.Budget.DailyRequests {
+		return fmt.Errorf("%w: %d of %d requests used today", ErrBudgetExceeded, requests, m.Budget.DailyRequests)
+	}
+	if m.Budget.DailyTokens > 0 && tokens >= m.Budget.DailyTokens {
+		return fmt.Errorf("%w: %d of %d tokens used today", ErrBudgetExceeded, tokens, m.Budget.DailyTokens)
+	}
+	return nil
+}
+
+// Generate implements Provider
+func (m *Metered) Generate(ctx context.Context, req Request) (*Response, error) {
+	if err := m.CheckBudget(); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	resp, err := m.Provider.Generate(ctx, req)
+
+	usage := db.AIUsage{
+		Task:      m.Task,
+		Model:     m.Provider.Name(),
+		LatencyMs: time.Since(start).Milliseconds(),
+		Success:   err == nil,
+	}
+	if err != nil {
+		usage.Error = err.Error()
+	} else {
+		usage.InputTokens = resp.Usage.InputTokens
+		usage.OutputTokens = resp.Usage.OutputTokens
+		// Some local servers don't report usage
+		if usage.InputTokens == 0 && usage.OutputTokens == 0 {
+			usage.InputTokens = EstimateTokens(req.Prompt)
+			usage.OutputTokens = EstimateTokens(resp.Text)
+		}
+	}
+	if recordErr := db.RecordAIUsage(usage); recordErr != nil {
+		fmt.Printf("Failed to record AI usage: %v\n", recordErr)
+	}
+
+	return resp, err
+}
+
+// EmbeddingModel implements Embedder
+func (m *Metered) EmbeddingModel() string {
+	if e, ok := m.Provider.(Embedder); ok {
+		return e.EmbeddingModel()
+	}
+	return ""
+}
+
+// Embed implements Embedder, metering like Generate
+func (m *Metered) Embed(ctx context.Context, texts []string) ([][]float32, Usage, error) {
+	e, ok := m.Provider.(Embedder)
+	if !ok {
+		return nil, Usage{}, fmt.Errorf("%s does not support embeddings", m.Provider.Name())
+	}
+	if err := m.CheckBudget(); err != nil {
+		return nil, Usage{}, err
+	}
+
+	start := time.Now()
+	vectors, usage, err := e.Embed(ctx, texts)
+
+	record := db.AIUsage{
+		Task:        m.Task,
+		Model:       e.EmbeddingModel(),
+		InputTokens: usage.InputTokens,
+		LatencyMs:   time.Since(start).Milliseconds(),
+		Success:     err == nil,
+	}
+	if err != nil {
+		record.Error = err.Error()
+	} else if record.InputTokens == 0 {
+		for _, text := range texts {
+			record.InputTokens += EstimateTokens(text)
+		}
+	}
+	if recordErr := db.RecordAIUsage(record); recordErr != nil {
+		fmt.Printf("Failed to record AI usage: %v\n", recordErr)
+	}
+
+	return vectors, usage, err
+}
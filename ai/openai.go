@@ -0,0 +1,195 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// OpenAI is a Provider for any server implementing the OpenAI chat
+// completions API, such as llama.cpp's server or Ollama
+type OpenAI struct {
+	Endpoint  string       // Base URL including /v1; defaults to DefaultOpenAIEndpoint
+	APIKey    string       // Optional; most local servers don't need one
+	Model     string       // Required by Ollama, ignored by llama.cpp
+	Embedding string       // Embedding model; defaults to DefaultOpenAIEmbeddingModel
+	Client    *http.Client // Defaults to http.DefaultClient
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatRequest struct {
+	Model          string         `json:"model"`
+	Messages       []chatMessage  `json:"messages"`
+	Temperature    float32        `json:"temperature"`
+	ResponseFormat map[string]any `json:"response_format,omitempty"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (o *OpenAI) endpoint() string {
+	if o.Endpoint == "" {
+		return DefaultOpenAIEndpoint
+	}
+	return strings.TrimSuffix(o.Endpoint, "/")
+}
+
+// Name implements Provider
+func (o *OpenAI) Name() string {
+	return ProviderOpenAI + "/" + o.Model
+}
+
+// Generate implements Provider
+func (o *OpenAI) Generate(ctx context.Context, req Request) (*Response, error) {
+	body := chatRequest{
+		Model:       o.Model,
+		Messages:    []chatMessage{{Role: "user", Content: req.Prompt}},
+		Temperature: req.Temperature,
+	}
+	if req.Schema != nil {
+		body.ResponseFormat = map[string]any{
+			"type": "json_schema",
+			"json_schema": map[string]any{
+				"name":   "result",
+				"schema": req.Schema,
+			},
+		}
+	}
+
+	var result chatResponse
+	status, err := o.post(ctx, "/chat/completions", body, &result)
+	if err != nil {
+		return nil, err
+	}
+	if result.Error != nil {
+		return nil, fmt.Errorf("%s: %s", o.endpoint(), result.Error.Message)
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", status)
+	}
+	if len(result.Choices) == 0 {
+		return nil, fmt.Errorf("empty response from %s", o.endpoint())
+	}
+
+	return &Response{
+		Text: result.Choices[0].Message.Content,
+		Usage: Usage{
+			InputTokens:  result.Usage.PromptTokens,
+			OutputTokens: result.Usage.CompletionTokens,
+		},
+	}, nil
+}
+
+type embeddingResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+	Usage struct {
+		PromptTokens int `json:"prompt_tokens"`
+	} `json:"usage"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (o *OpenAI) embedding() string {
+	if o.Embedding == "" {
+		return DefaultOpenAIEmbeddingModel
+	}
+	return o.Embedding
+}
+
+// EmbeddingModel implements Embedder
+func (o *OpenAI) EmbeddingModel() string {
+	return ProviderOpenAI + "/" + o.embedding()
+}
+
+// Embed implements Embedder
+func (o *OpenAI) Embed(ctx context.Context, texts []string) ([][]float32, Usage, error) {
+	var result embeddingResponse
+	status, err := o.post(ctx, "/embeddings", map[string]any{"model": o.embedding(), "input": texts}, &result)
+	if err != nil {
+		return nil, Usage{}, err
+	}
+	if result.Error != nil {
+		return nil, Usage{}, fmt.Errorf("%s: %s", o.endpoint(), result.Error.Message)
+	}
+	if status != http.StatusOK {
+		return nil, Usage{}, fmt.Errorf("unexpected status code: %d", status)
+	}
+	if len(result.Data) != len(texts) {
+		return nil, Usage{}, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(result.Data))
+	}
+
+	vectors := make([][]float32, len(texts))
+	for i, d := range result.Data {
+		index := d.Index
+		if index < 0 || index >= len(texts) {
+			index = i
+		}
+		vectors[index] = d.Embedding
+	}
+	return vectors, Usage{InputTokens: result.Usage.PromptTokens}, nil
+}
+
+// post sends a JSON request to the endpoint and decodes the JSON response into result
+func (o *OpenAI) post(ctx context.Context, path string, body any, result any) (int, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", o.endpoint()+path, bytes.NewReader(data))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if o.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+o.APIKey)
+	}
+
+	client := o.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to reach %s: %w", o.endpoint(), err)
+	}
+	defer resp.Body.Close()
+
+	respData, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read response: %w", err)
+	}
+	if err := json.Unmarshal(respData, result); err != nil {
+		return 0, fmt.Errorf("unexpected response (status %d): %s", resp.StatusCode, truncate(string(respData), 200))
+	}
+	return resp.StatusCode, nil
+}
+
+func truncate(s string, n int) string {
+	if runes := []rune(s); len(runes) > n {
+		return string(runes[:n]) + "..."
+	}
+	return s
+}
@@ -0,0 +1,213 @@
+package ai
+
+import (
+	"aslm/booth"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakeOpenAI serves handler at the given path and fails the test for any
+// other request
+func fakeOpenAI(t *testing.T, path string, handler func(t *testing.T, body map[string]any) (int, string)) *OpenAI {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != path {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+		if got := r.Header.Get("Content-Type"); got != "application/json" {
+			t.Errorf("Content-Type = %q", got)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer sk-test" {
+			t.Errorf("Authorization = %q", got)
+		}
+		data, _ := io.ReadAll(r.Body)
+		var body map[string]any
+		if err := json.Unmarshal(data, &body); err != nil {
+			t.Errorf("request is not JSON: %v", err)
+		}
+		status, response := handler(t, body)
+		w.WriteHeader(status)
+		io.WriteString(w, response)
+	}))
+	t.Cleanup(srv.Close)
+	// A trailing slash must not produce "//chat/completions"
+	return &OpenAI{Endpoint: srv.URL + "/v1/", APIKey: "sk-test", Model: "llama3.1"}
+}
+
+func TestOpenAIGenerateRequestShape(t *testing.T) {
+	schema := map[string]any{"type": "object"}
+	o := fakeOpenAI(t, "/v1/chat/completions", func(t *testing.T, body map[string]any) (int, string) {
+		if body["model"] != "llama3.1" {
+			t.Errorf("model = %v", body["model"])
+		}
+		messages, _ := body["messages"].([]any)
+		if len(messages) != 1 {
+			t.Fatalf("messages = %v", body["messages"])
+		}
+		msg := messages[0].(map[string]any)
+		if msg["role"] != "user" || msg["content"] != "hello" {
+			t.Errorf("message = %v", msg)
+		}
+		format, _ := body["response_format"].(map[string]any)
+		if format["type"] != "json_schema" {
+			t.Errorf("response_format = %v", body["response_format"])
+		}
+		jsonSchema, _ := format["json_schema"].(map[string]any)
+		if s, _ := jsonSchema["schema"].(map[string]any); s["type"] != "object" {
+			t.Errorf("schema = %v", jsonSchema["schema"])
+		}
+		return http.StatusOK, `{"choices":[{"message":{"role":"assistant","content":"hi"}}],"usage":{"prompt_tokens":12,"completion_tokens":3}}`
+	})
+
+	resp, err := o.Generate(context.Background(), Request{Prompt: "hello", Schema: schema})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text != "hi" {
+		t.Errorf("text = %q", resp.Text)
+	}
+	if resp.Usage != (Usage{InputTokens: 12, OutputTokens: 3}) {
+		t.Errorf("usage = %+v", resp.Usage)
+	}
+}
+
+func TestOpenAIGenerateWithoutSchemaOmitsResponseFormat(t *testing.T) {
+	o := fakeOpenAI(t, "/v1/chat/completions", func(t *testing.T, body map[string]any) (int, string) {
+		if _, ok := body["response_format"]; ok {
+			t.Errorf("response_format sent without a schema: %v", body["response_format"])
+		}
+		return http.StatusOK, `{"choices":[{"message":{"content":"plain"}}]}`
+	})
+	if _, err := o.Generate(context.Background(), Request{Prompt: "hello"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOpenAIExtractParsesSchemaResponse(t *testing.T) {
+	cards := []booth.ItemCard{
+		{ID: "111", Title: "Other", Shop: "A", URL: "https://booth.pm/ja/items/111", ImageURL: "https://booth.pximg.net/a/111.jpg"},
+		{ID: "222", Title: "Hat", Shop: "B", URL: "https://booth.pm/ja/items/222", ImageURL: "https://booth.pximg.net/b/222.jpg"},
+	}
+	cases := []struct {
+		name    string
+		content string
+		wantURL string
+		wantErr error
+	}{
+		{"match", `{"found":true,"itemId":"222","confidence":0.9}`, "https://booth.pm/ja/items/222", nil},
+		{"no match", `{"found":false,"itemId":"","confidence":0}`, "", ErrNoMatch},
+		{"low confidence", `{"found":true,"itemId":"222","confidence":0.2}`, "https://booth.pm/ja/items/222", ErrLowConfidence},
+		{"unknown item", `{"found":true,"itemId":"999","confidence":0.9}`, "", ErrInvalidResult},
+		{"not JSON", `the hat is 222`, "", ErrInvalidResult},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			o := fakeOpenAI(t, "/v1/chat/completions", func(t *testing.T, body map[string]any) (int, string) {
+				content, _ := json.Marshal(tc.content)
+				return http.StatusOK, `{"choices":[{"message":{"content":` + string(content) + `}}]}`
+			})
+			info, err := ExtractBoothProductInfo(context.Background(), o, "Hat", cards, 0)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tc.wantErr)
+			}
+			if tc.wantURL != "" && (info == nil || info.ProductURL != tc.wantURL || info.ShopName != "B") {
+				t.Errorf("info = %+v", info)
+			}
+		})
+	}
+}
+
+func TestOpenAIErrorStatuses(t *testing.T) {
+	cases := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr string
+	}{
+		{"error object", http.StatusBadRequest, `{"error":{"message":"model not found"}}`, "model not found"},
+		{"status without error object", http.StatusServiceUnavailable, `{}`, "unexpected status code: 503"},
+		{"non-JSON body", http.StatusBadGateway, `<html>Bad Gateway</html>`, "unexpected response (status 502)"},
+		{"no choices", http.StatusOK, `{"choices":[]}`, "empty response"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			o := fakeOpenAI(t, "/v1/chat/completions", func(t *testing.T, body map[string]any) (int, string) {
+				return tc.status, tc.body
+			})
+			_, err := o.Generate(context.Background(), Request{Prompt: "hello"})
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("err = %v, want it to contain %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestOpenAIUnreachableEndpoint(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+	o := &OpenAI{Endpoint: srv.URL + "/v1"}
+	if _, err := o.Generate(context.Background(), Request{Prompt: "hello"}); err == nil || !strings.Contains(err.Error(), "failed to reach") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestOpenAIEmbed(t *testing.T) {
+	o := fakeOpenAI(t, "/v1/embeddings", func(t *testing.T, body map[string]any) (int, string) {
+		if body["model"] != "bge-m3" {
+			t.Errorf("model = %v", body["model"])
+		}
+		input, _ := body["input"].([]any)
+		if len(input) != 2 || input[0] != "a" || input[1] != "b" {
+			t.Errorf("input = %v", body["input"])
+		}
+		// Out of order; the index decides where each vector goes
+		return http.StatusOK, `{"data":[{"index":1,"embedding":[0,1]},{"index":0,"embedding":[1,0]}],"usage":{"prompt_tokens":4}}`
+	})
+	o.Embedding = "bge-m3"
+
+	if got := o.EmbeddingModel(); got != "openai/bge-m3" {
+		t.Errorf("EmbeddingModel = %q", got)
+	}
+	vectors, usage, err := o.Embed(context.Background(), []string{"a", "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vectors) != 2 || vectors[0][0] != 1 || vectors[1][1] != 1 {
+		t.Errorf("vectors = %v", vectors)
+	}
+	if usage.InputTokens != 4 {
+		t.Errorf("usage = %+v", usage)
+	}
+}
+
+func TestOpenAIEmbedErrors(t *testing.T) {
+	cases := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr string
+	}{
+		{"count mismatch", http.StatusOK, `{"data":[{"index":0,"embedding":[1]}]}`, "expected 2 embeddings, got 1"},
+		{"error object", http.StatusNotFound, `{"error":{"message":"no embedding model"}}`, "no embedding model"},
+		{"server error", http.StatusInternalServerError, `{}`, "unexpected status code: 500"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			o := fakeOpenAI(t, "/v1/embeddings", func(t *testing.T, body map[string]any) (int, string) {
+				return tc.status, tc.body
+			})
+			_, _, err := o.Embed(context.Background(), []string{"a", "b"})
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("err = %v, want it to contain %q", err, tc.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package ai
+
+import (
+	"aslm/config"
+	"aslm/secret"
+	"context"
+	"fmt"
+)
+
+// Provider names accepted in config.Config.AIProvider
+const (
+	ProviderGemini = "gemini"
+	ProviderOpenAI = "openai" // Any OpenAI-compatible endpoint: llama.cpp, Ollama, LM Studio, ...
+)
+
+// Defaults used when the config leaves model or endpoint empty
+const (
+	DefaultGeminiModel          = "gemini-2.5-flash"
+	DefaultGeminiEmbeddingModel = "gemini-embedding-001"
+	DefaultOpenAIEndpoint       = "http://localhost:11434/v1" // Ollama
+	DefaultOpenAIEmbeddingModel = "bge-m3"                    // Multilingual, so Japanese and English queries meet
+)
+
+// Provider generates text with a language model
+type Provider interface {
+	// Name identifies the provider and model, e.g. "gemini/gemini-2.5-flash"
+	Name() string
+	Generate(ctx context.Context, req Request) (*Response, error)
+}
+
+// Embedder turns texts into vectors for semantic search. Both providers
+// implement it.
+type Embedder interface {
+	// EmbeddingModel identifies the vector space; vectors from different models can't be compared
+	EmbeddingModel() string
+	Embed(ctx context.Context, texts []string) ([][]float32, Usage, error)
+}
+
+// Request is a single prompt. If Schema is set the response must be JSON
+// matching it.
+type Request struct {
+	Prompt      string
+	Schema      map[string]any // JSON Schema of the response
+	Temperature float32
+}
+
+// Response is the text the model produced and what it cost
+type Response struct {
+	Text  string
+	Usage Usage
+}
+
+// Usage is the token count of one request
+type Usage struct {
+	InputTokens  int `json:"inputTokens"`
+	OutputTokens int `json:"outputTokens"`
+}
+
+// FromConfig returns the provider selected in the config, with its API key
+// from the secret store
+func FromConfig(cfg *config.Config) (Provider, error) {
+	switch cfg.AIProvider {
+	case "", ProviderGemini:
+		apiKey, err := secret.Get(secret.GeminiAPIKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read Gemini API key: %w", err)
+		}
+		if apiKey == "" {
+			return nil, fmt.Errorf("Gemini API key not configured")
+		}
+		return &Gemini{APIKey: apiKey, Model: cfg.AIModel, Embedding: cfg.AIEmbeddingModel}, nil
+	case ProviderOpenAI:
+		apiKey, err := secret.Get(secret.AIAPIKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read API key: %w", err)
+		}
+		return &OpenAI{Endpoint: cfg.AIEndpoint, APIKey: apiKey, Model: cfg.AIModel, Embedding: cfg.AIEmbeddingModel}, nil
+	}
+	return nil, fmt.Errorf("unknown AI provider: %s", cfg.AIProvider)
+}
@@ -0,0 +1,150 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// TagSuggestionInput is what the model sees about a product when suggesting tags
+type TagSuggestionInput struct {
+	Name       string   // Folder or product name
+	PageText   string   // Text of the Booth product page, if known
+	Assets     []string // Asset paths from the product's unitypackages
+	Vocabulary []string // Existing tags; suggestions should prefer these
+}
+
+// SuggestedTag is a single proposed tag
+type SuggestedTag struct {
+	Name   string `json:"name"`
+	IsNew  bool   `json:"isNew"` // Not in the existing vocabulary
+	Reason string `json:"reason"`
+}
+
+// TagSuggestions is the structured result of SuggestTags. Nothing is
+// applied; the user accepts or rejects each suggestion.
+type TagSuggestions struct {
+	Tags     []SuggestedTag `json:"tags"`
+	Category string         `json:"category"`
+	Avatars  []string       `json:"avatars"` // Avatars the product supports
+}
+
+// Categories the model chooses from
+var Categories = []string{"avatar", "outfit", "hair", "accessory", "texture", "shader", "gimmick", "tool", "motion", "world", "other"}
+
+// maxAssetsInPrompt caps how many asset paths are sent to keep the prompt small
+const maxAssetsInPrompt = 300
+
+var tagSuggestionSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"tags": map[string]any{
+			"type": "array",
+			"items": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"name":   map[string]any{"type": "string"},
+					"reason": map[string]any{"type": "string"},
+				},
+				"required": []string{"name", "reason"},
+			},
+		},
+		"category": map[string]any{"type": "string", "enum": Categories},
+		"avatars":  map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+	},
+	"required": []string{"tags", "category", "avatars"},
+}
+
+// SuggestTags asks the model for tags, a category and compatible avatars.
+// Tags are matched against the vocabulary afterwards, so IsNew doesn't
+// depend on the model getting it right.
+func SuggestTags(ctx context.Context, p Provider, input TagSuggestionInput) (*TagSuggestions, error) {
+	resp, err := p.Generate(ctx, Request{Prompt: tagSuggestionPrompt(input), Schema: tagSuggestionSchema, Temperature: 0.2})
+	if err != nil {
+		return nil, err
+	}
+
+	var result TagSuggestions
+	if err := json.Unmarshal([]byte(resp.Text), &result); err != nil {
+		return nil, fmt.Errorf("failed to parse tag suggestions: %w", err)
+	}
+
+	result.Tags = matchVocabulary(result.Tags, input.Vocabulary)
+	return &result, nil
+}
+
+func tagSuggestionPrompt(input TagSuggestionInput) string {
+	var b strings.Builder
+	b.WriteString(`You tag VRChat avatar assets in a personal asset library.
+Suggest 3-10 tags that describe the product below, the category that fits best,
+and the avatars it is made for (e.g. "マヌカ", "桔梗", "セレスティア").
+
+Rules:
+- Prefer tags from the existing vocabulary, spelled exactly as listed.
+- Only invent a new tag when nothing in the vocabulary fits.
+- Tags may be hierarchical with "/" (e.g. "衣装/トップス"), like the vocabulary.
+- Use avatar names as written on the product page. Leave avatars empty if unknown.
+- Give a short reason for each tag.
+
+`)
+
+	b.WriteString("Existing vocabulary:\n")
+	if len(input.Vocabulary) == 0 {
+		b.WriteString("(none yet)\n")
+	}
+	for _, tag := range input.Vocabulary {
+		b.WriteString("- " + tag + "\n")
+	}
+
+	b.WriteString("\nProduct name: " + input.Name + "\n")
+
+	if input.PageText != "" {
+		b.WriteString("\nProduct page text:\n" + input.PageText + "\n")
+	}
+
+	if len(input.Assets) > 0 {
+		b.WriteString("\nFiles in the unitypackage(s):\n")
+		assets := input.Assets
+		if len(assets) > maxAssetsInPrompt {
+			assets = assets[:maxAssetsInPrompt]
+		}
+		for _, asset := range assets {
+			b.WriteString(asset + "\n")
+		}
+		if len(input.Assets) > maxAssetsInPrompt {
+			fmt.Fprintf(&b, "(%d more files omitted)\n", len(input.Assets)-maxAssetsInPrompt)
+		}
+	}
+
+	return b.String()
+}
+
+// matchVocabulary maps suggestions onto the existing spelling of a tag
+// (case-insensitive), flags the rest as new and drops duplicates
+func matchVocabulary(tags []SuggestedTag, vocabulary []string) []SuggestedTag {
+	known := make(map[string]string, len(vocabulary))
+	for _, tag := range vocabulary {
+		known[strings.ToLower(tag)] = tag
+	}
+
+	seen := make(map[string]bool)
+	result := make([]SuggestedTag, 0, len(tags))
+	for _, tag := range tags {
+		tag.Name = strings.TrimSpace(tag.Name)
+		key := strings.ToLower(tag.Name)
+		if tag.Name == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		if existing, ok := known[key]; ok {
+			tag.Name = existing
+			tag.IsNew = false
+		} else {
+			tag.IsNew = true
+		}
+		result = append(result, tag)
+	}
+	return result
+}
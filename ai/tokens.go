@@ -0,0 +1,21 @@
+package ai
+
+import "unicode/utf8"
+
+// DefaultMaxInputTokens caps the prompt size when the config doesn't set one
+const DefaultMaxInputTokens = 4000
+
+// EstimateTokens roughly estimates how many tokens a text uses. ASCII text
+// averages about four characters per token; Japanese and other non-ASCII
+// text is counted as one token per character, which errs on the high side.
+func EstimateTokens(s string) int {
+	ascii, other := 0, 0
+	for _, r := range s {
+		if r < utf8.RuneSelf {
+			ascii++
+		} else {
+			other++
+		}
+	}
+	return (ascii+3)/4 + other
+}
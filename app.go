@@ -1,24 +1,33 @@
 package main
 
 import (
+	"aslm/ai"
 	"aslm/booth"
 	"aslm/config"
 	"aslm/db"
-	"aslm/gemini"
+	"aslm/inbox"
+	"aslm/library"
+	"aslm/secret"
+	"aslm/server"
 	"context"
 	"fmt"
-	"io"
-	"net/http"
-	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
-	"time"
 )
 
 // App struct
 type App struct {
-	ctx context.Context
+	ctx         context.Context
+	inbox       *inbox.Watcher
+	stopInbox   context.CancelFunc
+	server      *server.Server
+	integrity   *db.IntegrityReport // Result of the startup database check
+	stopBackups context.CancelFunc
+	stopSync    context.CancelFunc
+	stopRoots   context.CancelFunc
+
+	releaseInstance func() // Removes the running-instance marker
 }
 
 // FileItem represents a file or directory
@@ -44,6 +53,74 @@ func (a *App) startup(ctx context.Context) {
 	a.ctx = ctx
 	if err := db.InitDB(); err != nil {
 		fmt.Printf("Error initializing DB: %v\n", err)
+	} else {
+		a.checkDatabase()
+	}
+	if err := secret.MigrateConfig(); err != nil {
+		fmt.Printf("Error migrating API keys: %v\n", err)
+	}
+	fmt.Printf("Data directory: %s\n", config.DataDir())
+	if cfg, err := config.LoadConfig(); err == nil {
+		if keys := cfg.Overrides(); len(keys) > 0 {
+			fmt.Printf("Settings overridden from the environment: %s\n", strings.Join(keys, ", "))
+		}
+		if _, err := syncRoots(cfg); err != nil {
+			fmt.Printf("Error checking library roots: %v\n", err)
+		}
+	}
+	if release, err := db.ClaimInstance(); err != nil {
+		fmt.Printf("Failed to mark the database as in use: %v\n", err)
+	} else {
+		a.releaseInstance = release
+	}
+	a.startWorkers()
+}
+
+// shutdown stops the background workers before the app exits
+func (a *App) shutdown(ctx context.Context) {
+	a.stopWorkers()
+	if a.releaseInstance != nil {
+		a.releaseInstance()
+	}
+}
+
+// startWorkers starts everything that uses the database in the background
+func (a *App) startWorkers() {
+	a.startInbox()
+	a.startServer()
+	a.startBackups()
+	a.startSync()
+	a.startRootMonitor()
+}
+
+// stopWorkers stops the background workers and waits for them to finish,
+// e.g. before the database is replaced
+func (a *App) stopWorkers() {
+	for _, stop := range []*context.CancelFunc{&a.stopInbox, &a.stopBackups, &a.stopSync, &a.stopRoots} {
+		if *stop != nil {
+			(*stop)()
+			*stop = nil
+		}
+	}
+	a.inbox = nil
+	if a.server != nil {
+		a.server.Stop()
+		a.server = nil
+	}
+}
+
+// runWorker runs work in the background. The returned function cancels it
+// and waits until it has returned.
+func (a *App) runWorker(work func(ctx context.Context)) context.CancelFunc {
+	ctx, cancel := context.WithCancel(a.ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		work(ctx)
+	}()
+	return func() {
+		cancel()
+		<-done
 	}
 }
 
@@ -57,6 +134,11 @@ func (a *App) UpdateProduct(path string, url string, imageUrl string, shopName s
 	return db.UpdateProduct(path, url, imageUrl, shopName, tags)
 }
 
+// PatchProduct changes only the fields set in patch, e.g. a single tag or the shop name
+func (a *App) PatchProduct(path string, patch db.ProductPatch) error {
+	return db.PatchProduct(path, patch)
+}
+
 // ListFiles returns a list of files and directories in the given path
 func (a *App) ListFiles(path string) ([]FileItem, error) {
 	entries, err := os.ReadDir(path)
@@ -64,8 +146,17 @@ func (a *App) ListFiles(path string) ([]FileItem, error) {
 		return nil, err
 	}
 
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
 	var items []FileItem
 	for _, entry := range entries {
+		if entry.Name() == library.MarkerFile {
+			continue
+		}
+
 		itemType := "file"
 		if entry.IsDir() {
 			itemType = "folder"
@@ -73,10 +164,13 @@ func (a *App) ListFiles(path string) ([]FileItem, error) {
 
 		fullPath := filepath.Join(path, entry.Name())
 
-		// Register product if not exists (auto-discovery)
-		// In a real app, maybe we only do this for folders or specific files
-		if itemType == "folder" {
-			_ = db.RegisterProduct(fullPath, entry.Name())
+		// Register product if not exists (auto-discovery). Inside a library
+		// root, the root's scan rules decide which folders are products, and
+		// folders the inbox created to group products (e.g. shops) never are.
+		if itemType == "folder" && !library.IsGroupFolder(fullPath) {
+			if root := library.RootOf(cfg.Roots, fullPath); root == nil || library.Allows(*root, fullPath) {
+				_ = db.RegisterProduct(fullPath, entry.Name())
+			}
 		}
 
 		// Get info from DB
@@ -122,9 +216,10 @@ func (a *App) GetParentProduct(path string) (*db.ProductInfo, error) {
 
 // BoothInfo represents information from Booth
 type BoothInfo struct {
-	ProductURL string `json:"productUrl"`
-	ImageURL   string `json:"imageUrl"`
-	ShopName   string `json:"shopName"`
+	ProductURL string  `json:"productUrl"`
+	ImageURL   string  `json:"imageUrl"`
+	ShopName   string  `json:"shopName"`
+	Confidence float64 `json:"confidence,omitempty"` // Set for AI results, 0-1
 }
 
 // AutoFetchBoothInfo automatically fetches product info from Booth based on folder name
@@ -152,40 +247,46 @@ func (a *App) FetchBoothImageFromURL(productURL string) (string, error) {
 	return info.ImageURL, nil
 }
 
-// GetGeminiApiKey retrieves the saved Gemini API key
-func (a *App) GetGeminiApiKey() (string, error) {
-	cfg, err := config.LoadConfig()
-	if err != nil {
-		return "", err
-	}
-	return cfg.GeminiAPIKey, nil
+// SecretStatus describes a stored API key without revealing it
+type SecretStatus struct {
+	Configured bool   `json:"configured"`
+	Masked     string `json:"masked"`  // e.g. "AIza…x9Qk"
+	Storage    string `json:"storage"` // "keyring" or "file"
+}
+
+// GetGeminiApiKeyStatus reports whether a Gemini API key is saved. The key
+// itself is never returned to the frontend.
+func (a *App) GetGeminiApiKeyStatus() (*SecretStatus, error) {
+	return secretStatus(secret.GeminiAPIKey)
 }
 
-// SaveGeminiApiKey saves the Gemini API key
+// SaveGeminiApiKey saves the Gemini API key in the OS keyring (or the
+// encrypted fallback file); an empty key removes it
 func (a *App) SaveGeminiApiKey(apiKey string) error {
-	cfg, err := config.LoadConfig()
+	return secret.Set(secret.GeminiAPIKey, strings.TrimSpace(apiKey))
+}
+
+func secretStatus(name string) (*SecretStatus, error) {
+	value, err := secret.Get(name)
 	if err != nil {
-		// Create default config if doesn't exist
-		cfg = &config.Config{
-			HomePath:     "D:/VRChatAssetPack",
-			GeminiAPIKey: apiKey,
-		}
-	} else {
-		cfg.GeminiAPIKey = apiKey
+		return nil, err
 	}
-	return config.SaveConfig(cfg)
+	if value == "" {
+		return &SecretStatus{}, nil
+	}
+	return &SecretStatus{
+		Configured: true,
+		Masked:     secret.Mask(value),
+		Storage:    secret.Backend(name),
+	}, nil
 }
 
-// FetchBoothInfoWithGemini uses Gemini API to fetch Booth product info
+// FetchBoothInfoWithGemini uses the configured AI provider (Gemini by default)
+// to fetch Booth product info
 func (a *App) FetchBoothInfoWithGemini(folderName string) (*BoothInfo, error) {
-	// Get API key
-	apiKey, err := a.GetGeminiApiKey()
+	provider, err := aiProvider(ai.TaskBoothLookup)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get API key: %w", err)
-	}
-
-	if apiKey == "" {
-		return nil, fmt.Errorf("Gemini API key not configured")
+		return nil, err
 	}
 
 	// Extract clean search query
@@ -194,66 +295,37 @@ func (a *App) FetchBoothInfoWithGemini(folderName string) (*BoothInfo, error) {
 		cleanQuery = folderName
 	}
 
-	// Fetch Booth search page HTML
-	searchURL := fmt.Sprintf("https://booth.pm/ja/search/%s", cleanQuery)
-	fmt.Printf("Searching Booth: %s\n", searchURL)
-
-	client := &http.Client{
-		Timeout: 10 * time.Second,
-	}
-
-	req, err := http.NewRequest("GET", searchURL, nil)
-	if err != nil {
-		fmt.Printf("Failed to create request: %v\n", err)
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
-
-	resp, err := client.Do(req)
+	// Reduce the search page to its item cards before asking the model
+	fmt.Printf("Searching Booth: %s\n", booth.SearchURL(cleanQuery))
+	page, err := booth.FetchSearchPage(cleanQuery)
 	if err != nil {
 		fmt.Printf("Failed to fetch search page: %v\n", err)
-		return nil, fmt.Errorf("failed to fetch search page: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		fmt.Printf("Unexpected status code: %d\n", resp.StatusCode)
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return nil, err
 	}
+	cards := booth.ParseSearchResults(page)
+	fmt.Printf("Found %d candidates in %d bytes of HTML\n", len(cards), len(page))
 
-	body, err := io.ReadAll(resp.Body)
+	cfg, err := config.LoadConfig()
 	if err != nil {
-		fmt.Printf("Failed to read response: %v\n", err)
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return nil, fmt.Errorf("failed to load config: %w", err)
 	}
 
-	fmt.Printf("Fetched HTML size: %d bytes\n", len(body))
+	// Use the model to pick the matching product
+	ctx, cancel := context.WithTimeout(context.Background(), aiTimeout)
+	defer cancel()
 
-	// Use Gemini to extract product info
-	info, err := gemini.ExtractBoothProductInfo(string(body), apiKey)
+	info, err := ai.ExtractBoothProductInfo(ctx, provider, cleanQuery, cards, cfg.AIMaxInputTokens)
 	if err != nil {
-		fmt.Printf("Failed to extract with Gemini: %v\n", err)
-		return nil, fmt.Errorf("failed to extract with Gemini: %w", err)
+		fmt.Printf("Failed to extract with %s: %v\n", provider.Name(), err)
+		return nil, fmt.Errorf("failed to extract with %s: %w", provider.Name(), err)
 	}
 
-	fmt.Printf("Gemini extracted info: %+v\n", info)
-
-	// Use Gemini's extracted shop name, or fall back to URL inference if empty
-	shopName := info.ShopName
-	if shopName == "" && info.ProductURL != "" {
-		if u, err := url.Parse(info.ProductURL); err == nil {
-			host := u.Hostname()
-			parts := strings.Split(host, ".")
-			if len(parts) >= 3 {
-				shopName = parts[0]
-			}
-		}
-	}
+	fmt.Printf("%s extracted info: %+v\n", provider.Name(), info)
 
 	return &BoothInfo{
 		ProductURL: info.ProductURL,
 		ImageURL:   info.ImageURL,
-		ShopName:   shopName,
+		ShopName:   info.ShopName,
+		Confidence: info.Confidence,
 	}, nil
 }
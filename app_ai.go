@@ -0,0 +1,144 @@
+package main
+
+import (
+	"aslm/ai"
+	"aslm/config"
+	"aslm/db"
+	"aslm/secret"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// aiTimeout bounds a single AI request; local models can be slow
+const aiTimeout = 2 * time.Minute
+
+// AISettings selects the model used for extraction and tag suggestions
+type AISettings struct {
+	Provider string       `json:"provider"` // "gemini" or "openai"
+	Model    string       `json:"model"`
+	Endpoint string       `json:"endpoint"`
+	APIKey   SecretStatus `json:"apiKey"` // Key for the OpenAI-compatible server; set with SaveAIApiKey
+}
+
+// AIUsageReport is what GetAIUsage returns
+type AIUsageReport struct {
+	Days              []db.AIUsageSummary `json:"days"`
+	TodayRequests     int                 `json:"todayRequests"`
+	TodayTokens       int                 `json:"todayTokens"`
+	DailyTokenLimit   int                 `json:"dailyTokenLimit"`   // 0 is unlimited
+	DailyRequestLimit int                 `json:"dailyRequestLimit"` // 0 is unlimited
+}
+
+// GetAISettings returns the configured AI provider, model and endpoint
+func (a *App) GetAISettings() (*AISettings, error) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	settings := &AISettings{
+		Provider: cfg.AIProvider,
+		Model:    cfg.AIModel,
+		Endpoint: cfg.AIEndpoint,
+	}
+	status, err := secretStatus(secret.AIAPIKey)
+	if err != nil {
+		return nil, err
+	}
+	settings.APIKey = *status
+	if settings.Provider == "" {
+		settings.Provider = ai.ProviderGemini
+	}
+	return settings, nil
+}
+
+// SaveAISettings saves the AI provider, model and endpoint. The API key is
+// saved separately with SaveAIApiKey. Model names are specific to a
+// provider, so switching providers without choosing a new model falls back
+// to the new provider's default models.
+func (a *App) SaveAISettings(settings AISettings) error {
+	if settings.Provider != ai.ProviderGemini && settings.Provider != ai.ProviderOpenAI {
+		return fmt.Errorf("unknown AI provider: %s", settings.Provider)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+	current := cfg.AIProvider
+	if current == "" {
+		current = ai.ProviderGemini
+	}
+	if settings.Provider != current {
+		if settings.Model == cfg.AIModel {
+			settings.Model = ""
+		}
+		cfg.AIEmbeddingModel = ""
+	}
+	cfg.AIProvider = settings.Provider
+	cfg.AIModel = settings.Model
+	cfg.AIEndpoint = settings.Endpoint
+	return saveValidConfig(cfg, "aiProvider", "aiEndpoint")
+}
+
+// SaveAIApiKey saves the key for the OpenAI-compatible server in secure
+// storage; an empty key removes it
+func (a *App) SaveAIApiKey(apiKey string) error {
+	return secret.Set(secret.AIAPIKey, strings.TrimSpace(apiKey))
+}
+
+// GetAIUsage returns AI usage per day and task for the last days days,
+// and today's totals against the configured budget
+func (a *App) GetAIUsage(days int) (*AIUsageReport, error) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &AIUsageReport{
+		DailyTokenLimit:   cfg.AIDailyTokens,
+		DailyRequestLimit: cfg.AIDailyRequests,
+	}
+	if report.TodayRequests, report.TodayTokens, err = db.AIUsageToday(); err != nil {
+		return nil, err
+	}
+	if report.Days, err = db.AIUsageByDay(days); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// SetAIBudget sets the daily token and request budget (0 for unlimited)
+func (a *App) SetAIBudget(dailyTokens int, dailyRequests int) error {
+	if dailyTokens < 0 || dailyRequests < 0 {
+		return fmt.Errorf("budget cannot be negative")
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+	cfg.AIDailyTokens = dailyTokens
+	cfg.AIDailyRequests = dailyRequests
+	return config.SaveConfig(cfg)
+}
+
+// aiProvider returns the provider selected in the config, metered for task
+// and limited by the configured daily budget
+func aiProvider(task string) (*ai.Metered, error) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	provider, err := ai.FromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &ai.Metered{
+		Provider: provider,
+		Task:     task,
+		Budget:   ai.Budget{DailyTokens: cfg.AIDailyTokens, DailyRequests: cfg.AIDailyRequests},
+	}, nil
+}
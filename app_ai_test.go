@@ -0,0 +1,38 @@
+package main
+
+import (
+	"aslm/ai"
+	"aslm/config"
+	"testing"
+)
+
+func TestSaveAISettingsResetsModelWhenProviderChanges(t *testing.T) {
+	config.SetDataDir(t.TempDir())
+	app := &App{}
+
+	if err := app.SaveAISettings(AISettings{Provider: ai.ProviderGemini, Model: "gemini-2.5-pro"}); err != nil {
+		t.Fatal(err)
+	}
+	cfg, _ := config.LoadConfig()
+	cfg.AIEmbeddingModel = "gemini-embedding-001"
+	if err := config.SaveConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	// The settings screen still holds the Gemini model name
+	if err := app.SaveAISettings(AISettings{Provider: ai.ProviderOpenAI, Model: "gemini-2.5-pro", Endpoint: "http://localhost:11434/v1"}); err != nil {
+		t.Fatal(err)
+	}
+	cfg, _ = config.LoadConfig()
+	if cfg.AIModel != "" || cfg.AIEmbeddingModel != "" {
+		t.Errorf("model = %q, embedding = %q; want both reset", cfg.AIModel, cfg.AIEmbeddingModel)
+	}
+
+	// A model chosen together with the provider is kept
+	if err := app.SaveAISettings(AISettings{Provider: ai.ProviderGemini, Model: "gemini-2.5-flash"}); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, _ = config.LoadConfig(); cfg.AIModel != "gemini-2.5-flash" {
+		t.Errorf("model = %q", cfg.AIModel)
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"aslm/archive"
+	"aslm/config"
+	"aslm/db"
+	"aslm/fsutil"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// maxArchivePreviewBytes limits how much of a readme is returned for preview
+const maxArchivePreviewBytes = 256 * 1024
+
+// InspectArchive lists the contents of a .zip or .7z file without extracting it
+func (a *App) InspectArchive(path string) (*archive.Listing, error) {
+	return archive.List(path)
+}
+
+// ReadArchiveEntry returns the text of a file inside an archive (e.g. a readme)
+func (a *App) ReadArchiveEntry(path string, name string) (string, error) {
+	return archive.ReadEntry(path, name, maxArchivePreviewBytes)
+}
+
+// ExtractArchive extracts a downloaded .zip into a new product folder in the home root
+// and returns the folder path. Progress is reported through "archive:extract:progress"
+// events. Product metadata linked to the archive is carried over to the new folder.
+func (a *App) ExtractArchive(archivePath string) (string, error) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return "", fmt.Errorf("failed to load config: %w", err)
+	}
+
+	name := fsutil.SanitizeName(strings.TrimSuffix(filepath.Base(archivePath), filepath.Ext(archivePath)))
+	home, err := homeRoot(cfg)
+	if err != nil {
+		return "", err
+	}
+	dest := fsutil.UniqueDir(filepath.Join(home, name))
+
+	err = archive.ExtractZip(archivePath, dest, archive.ExtractOptions{
+		StripSingleRoot: true,
+		Progress: func(p archive.Progress) {
+			if a.ctx != nil {
+				runtime.EventsEmit(a.ctx, "archive:extract:progress", p)
+			}
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	// Register the new folder with the metadata FilePreview shows for the archive:
+	// its own row, or the product folder it was downloaded into
+	info, err := db.GetProductInfo(archivePath)
+	if err != nil {
+		return dest, err
+	}
+	if info == nil {
+		if info, err = db.GetParentProductInfo(archivePath); err != nil {
+			return dest, err
+		}
+	}
+	if err := db.RegisterProduct(dest, filepath.Base(dest)); err != nil {
+		return dest, err
+	}
+	if info != nil {
+		if err := db.UpdateProduct(dest, info.Url, info.ImageUrl, info.ShopName, info.Tags); err != nil {
+			return dest, err
+		}
+	}
+
+	return dest, nil
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"aslm/backup"
+	"aslm/config"
+	"aslm/db"
+	"fmt"
+	"strings"
+)
+
+// BackupSettings controls automatic database backups
+type BackupSettings struct {
+	Enabled       bool   `json:"enabled"`
+	IntervalHours int    `json:"intervalHours"` // 0 uses the default (24)
+	Keep          int    `json:"keep"`          // 0 uses the default (7)
+	Dir           string `json:"dir"`
+}
+
+// checkDatabase runs the full integrity check at startup and logs what it
+// found. The report is kept for the frontend.
+func (a *App) checkDatabase() {
+	report, err := db.CheckIntegrity(true)
+	if err != nil {
+		fmt.Printf("Database check failed: %v\n", err)
+		report = &db.IntegrityReport{Problems: []string{err.Error()}, ForeignKeys: []string{}}
+	}
+	a.integrity = report
+	if report.OK {
+		return
+	}
+
+	fmt.Printf("Database check found %d problems:\n", len(report.Problems)+len(report.ForeignKeys))
+	for _, p := range append(report.Problems, report.ForeignKeys...) {
+		fmt.Printf("  %s\n", p)
+	}
+	fmt.Printf("Automatic backups are paused; restore a backup from %s\n", backup.Dir())
+}
+
+// startBackups starts the backup scheduler if automatic backups are enabled.
+// A database that failed the startup check is not backed up.
+func (a *App) startBackups() {
+	if a.stopBackups != nil {
+		a.stopBackups()
+		a.stopBackups = nil
+	}
+	cfg, err := config.LoadConfig()
+	if err != nil || !cfg.AutoBackup || a.integrity == nil || !a.integrity.OK {
+		return
+	}
+
+	interval, keep := backup.Settings(cfg)
+	a.stopBackups = a.runWorker((&backup.Scheduler{Interval: interval, Keep: keep}).Run)
+}
+
+// GetDatabaseReport returns the result of the integrity check run at startup
+func (a *App) GetDatabaseReport() *db.IntegrityReport {
+	return a.integrity
+}
+
+// CheckDatabase runs the full integrity and foreign key check again
+func (a *App) CheckDatabase() (*db.IntegrityReport, error) {
+	report, err := db.CheckIntegrity(true)
+	if err != nil {
+		return nil, err
+	}
+	a.integrity = report
+	return report, nil
+}
+
+// ListBackups returns the database backups, newest first
+func (a *App) ListBackups() ([]backup.Info, error) {
+	return backup.List()
+}
+
+// CreateBackup backs up the database now and rotates old backups
+func (a *App) CreateBackup() (*backup.Info, error) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	_, keep := backup.Settings(cfg)
+	return backup.Create(keep)
+}
+
+// RestoreBackup replaces the database with the named backup. The current
+// database is backed up first. The inbox, API server, sync and backups are
+// stopped while the database is swapped and restarted afterwards; backups
+// only once the restored database passes the check.
+func (a *App) RestoreBackup(name string) (*db.IntegrityReport, error) {
+	a.stopWorkers()
+	defer a.startWorkers()
+
+	if err := backup.Restore(strings.TrimSpace(name)); err != nil {
+		return nil, err
+	}
+	return a.CheckDatabase()
+}
+
+// GetBackupSettings returns the automatic backup settings
+func (a *App) GetBackupSettings() (*BackupSettings, error) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	return &BackupSettings{
+		Enabled:       cfg.AutoBackup,
+		IntervalHours: cfg.BackupInterval,
+		Keep:          cfg.BackupKeep,
+		Dir:           backup.Dir(),
+	}, nil
+}
+
+// SaveBackupSettings saves the automatic backup settings and restarts the scheduler
+func (a *App) SaveBackupSettings(settings BackupSettings) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+	cfg.AutoBackup = settings.Enabled
+	cfg.BackupInterval = settings.IntervalHours
+	cfg.BackupKeep = settings.Keep
+	if err := saveValidConfig(cfg, "backupIntervalHours", "backupKeep"); err != nil {
+		return err
+	}
+	a.startBackups()
+	return nil
+}
@@ -0,0 +1,47 @@
+package main
+
+import "aslm/db"
+
+// FindProducts returns the products matching a query
+func (a *App) FindProducts(query db.ProductQuery) ([]db.ProductInfo, error) {
+	return db.FindProducts(query)
+}
+
+// SaveQuery stores a query under a name for later bulk edits
+func (a *App) SaveQuery(name string, query db.ProductQuery) error {
+	return db.SaveQuery(name, query)
+}
+
+// ListSavedQueries returns all saved queries
+func (a *App) ListSavedQueries() ([]db.SavedQuery, error) {
+	return db.ListSavedQueries()
+}
+
+// DeleteSavedQuery removes a saved query
+func (a *App) DeleteSavedQuery(name string) error {
+	return db.DeleteSavedQuery(name)
+}
+
+// BulkEditProducts applies add/remove tags and shop/url changes to every
+// path in one transaction and returns a result per path
+func (a *App) BulkEditProducts(paths []string, patch db.ProductPatch) ([]db.BulkResult, error) {
+	return db.BulkEdit(paths, patch)
+}
+
+// BulkEditSavedQuery applies a patch to every product currently matching a saved query
+func (a *App) BulkEditSavedQuery(name string, patch db.ProductPatch) ([]db.BulkResult, error) {
+	query, err := db.GetSavedQuery(name)
+	if err != nil {
+		return nil, err
+	}
+	products, err := db.FindProducts(*query)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, len(products))
+	for i, p := range products {
+		paths[i] = p.Path
+	}
+	return db.BulkEdit(paths, patch)
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"aslm/catalog"
+	"fmt"
+	"os"
+)
+
+// ExportCatalog writes the metadata of every product to path. format is
+// "json" or "csv"; empty picks it from the file extension.
+func (a *App) ExportCatalog(path string, format string) (int, error) {
+	if format == "" {
+		format = catalog.FormatOf(path)
+	}
+	c, err := catalog.Build()
+	if err != nil {
+		return 0, err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return 0, err
+	}
+	if err := catalog.Write(f, c, format); err != nil {
+		f.Close()
+		return 0, err
+	}
+	return len(c.Products), f.Close()
+}
+
+// ImportCatalog merges an exported catalog into the library. strategy is
+// "keep" (local values win), "overwrite" or "merge" (combine tags). With
+// dryRun nothing is changed and the result shows what would be.
+func (a *App) ImportCatalog(path string, strategy string, dryRun bool) (*catalog.ImportResult, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	c, err := catalog.Read(f, catalog.FormatOf(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return catalog.Import(c, catalog.ImportOptions{Strategy: strategy, DryRun: dryRun})
+}
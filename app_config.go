@@ -0,0 +1,42 @@
+package main
+
+import (
+	"aslm/config"
+	"aslm/organize"
+	"fmt"
+)
+
+// ValidateConfig checks the saved settings and returns every problem found,
+// e.g. a library folder that doesn't exist or an invalid endpoint URL
+func (a *App) ValidateConfig() ([]config.FieldError, error) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	problems := cfg.Validate()
+	templates := []struct {
+		field string
+		value string
+	}{
+		{"inboxTemplate", cfg.InboxTemplate},
+		{"organizeTemplate", cfg.OrganizeTemplate},
+	}
+	for _, t := range templates {
+		if t.value == "" {
+			continue // The default is used
+		}
+		if err := organize.Validate(t.value); err != nil {
+			problems = append(problems, config.FieldError{Field: t.field, Message: err.Error(), Severity: config.SeverityError})
+		}
+	}
+	return problems, nil
+}
+
+// saveValidConfig saves cfg unless the given fields have errors
+func saveValidConfig(cfg *config.Config, fields ...string) error {
+	if err := config.ValidationError(cfg.Validate(), fields...); err != nil {
+		return fmt.Errorf("invalid settings: %w", err)
+	}
+	return config.SaveConfig(cfg)
+}
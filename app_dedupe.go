@@ -0,0 +1,29 @@
+package main
+
+import (
+	"aslm/config"
+	"aslm/dedupe"
+	"aslm/library"
+	"fmt"
+)
+
+// FindDuplicates scans every online library root for files with identical content
+func (a *App) FindDuplicates() (*dedupe.Report, error) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	// Offline roots are skipped so an unplugged drive doesn't fail the scan
+	var paths []string
+	for _, r := range library.Online(cfg.Roots) {
+		paths = append(paths, r.Path)
+	}
+	return dedupe.Scan(paths, dedupe.Options{})
+}
+
+// ResolveDuplicates resolves a duplicate group by deleting, hardlinking or keeping the copies.
+// action is one of "delete", "hardlink" or "keep".
+func (a *App) ResolveDuplicates(hash string, keepPath string, paths []string, action string) error {
+	return dedupe.Resolve(hash, keepPath, paths, dedupe.Action(action))
+}
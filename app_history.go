@@ -0,0 +1,22 @@
+package main
+
+import (
+	"aslm/db"
+	"aslm/history"
+)
+
+// Undo reverts the most recent metadata change or file move and returns the
+// operations that were undone (empty if there was nothing to undo)
+func (a *App) Undo() ([]db.Operation, error) {
+	return history.Undo()
+}
+
+// Redo re-applies the most recently undone change
+func (a *App) Redo() ([]db.Operation, error) {
+	return history.Redo()
+}
+
+// History returns the change timeline of a product, newest first
+func (a *App) History(path string) ([]db.Operation, error) {
+	return db.ProductHistory(path)
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"aslm/config"
+	"aslm/db"
+	"aslm/inbox"
+	"aslm/organize"
+	"fmt"
+	"strings"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// InboxSettings are the folder watched for downloads and where they are filed
+type InboxSettings struct {
+	Path     string `json:"path"`     // Empty disables the inbox
+	Template string `json:"template"` // Empty uses config.DefaultInboxTemplate
+}
+
+// startInbox (re)starts watching the configured inbox folder, if any.
+// Filed and queued downloads are reported through "inbox:<event>" events.
+func (a *App) startInbox() {
+	if a.stopInbox != nil {
+		a.stopInbox()
+		a.stopInbox = nil
+	}
+	a.inbox = nil
+	cfg, err := config.LoadConfig()
+	if err != nil || cfg.InboxPath == "" || cfg.HomeRoot() == "" {
+		return
+	}
+
+	a.inbox = &inbox.Watcher{
+		Dir:      cfg.InboxPath,
+		Home:     cfg.HomeRoot(),
+		Template: cfg.InboxFolderTemplate(),
+		OnEvent: func(event string, item db.InboxItem) {
+			runtime.EventsEmit(a.ctx, "inbox:"+event, item)
+		},
+	}
+	a.stopInbox = a.runWorker(a.inbox.Run)
+}
+
+// GetInboxSettings returns the inbox folder and filing template
+func (a *App) GetInboxSettings() (*InboxSettings, error) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	return &InboxSettings{Path: cfg.InboxPath, Template: cfg.InboxTemplate}, nil
+}
+
+// SaveInboxSettings saves the inbox folder and filing template and restarts
+// the watcher with them
+func (a *App) SaveInboxSettings(settings InboxSettings) error {
+	settings.Template = strings.TrimSpace(settings.Template)
+	if settings.Template != "" {
+		if err := organize.Validate(settings.Template); err != nil {
+			return fmt.Errorf("invalid inbox template: %w", err)
+		}
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+	cfg.InboxPath = strings.TrimSpace(settings.Path)
+	cfg.InboxTemplate = settings.Template
+	if err := saveValidConfig(cfg, "inboxPath"); err != nil {
+		return err
+	}
+	a.startInbox()
+	return nil
+}
+
+// ScanInbox checks the inbox folder immediately instead of waiting for the next poll
+func (a *App) ScanInbox() error {
+	if a.inbox == nil {
+		return fmt.Errorf("inbox folder not configured")
+	}
+	return a.inbox.Scan()
+}
+
+// ListInboxItems returns inbox items with the given status ("pending", "filed",
+// "rejected", "failed"), or all items if status is empty
+func (a *App) ListInboxItems(status string) ([]db.InboxItem, error) {
+	return db.ListInboxItems(status)
+}
+
+// ApproveInboxItem files a queued download using the (possibly corrected) metadata
+func (a *App) ApproveInboxItem(id int64, title string, shopName string, url string, imageUrl string) (*db.InboxItem, error) {
+	item, err := db.GetInboxItem(id)
+	if err != nil {
+		return nil, err
+	}
+	if item == nil {
+		return nil, fmt.Errorf("inbox item not found: %d", id)
+	}
+	if item.Status == db.InboxFiled {
+		return nil, fmt.Errorf("inbox item already filed: %d", id)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	item.Title = title
+	item.ShopName = shopName
+	item.Url = url
+	item.ImageUrl = imageUrl
+	home, err := homeRoot(cfg)
+	if err != nil {
+		return nil, err
+	}
+	item.Destination, err = inbox.Destination(home, cfg.InboxFolderTemplate(), organize.Fields{
+		Title:   title,
+		Shop:    shopName,
+		Version: organize.ExtractVersion(item.Path),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := inbox.File(item, nil); err != nil {
+		// A filed item was moved but not registered; keep its new path
+		if item.Status != db.InboxFiled {
+			item.Status = db.InboxFailed
+		}
+		item.Reason = err.Error()
+		db.UpdateInboxItem(item)
+		return nil, err
+	}
+
+	item.Reason = "approved"
+	if err := db.UpdateInboxItem(item); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// RejectInboxItem leaves a queued download in the inbox and stops suggesting it
+func (a *App) RejectInboxItem(id int64) error {
+	item, err := db.GetInboxItem(id)
+	if err != nil {
+		return err
+	}
+	if item == nil {
+		return fmt.Errorf("inbox item not found: %d", id)
+	}
+
+	item.Status = db.InboxRejected
+	return db.UpdateInboxItem(item)
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"aslm/config"
+	"aslm/db"
+	"aslm/organize"
+	"fmt"
+)
+
+// PlanReorganize returns the moves a reorganize with the given template would make,
+// without touching anything. An empty template uses the configured one.
+func (a *App) PlanReorganize(template string) (*organize.Plan, error) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	if template == "" {
+		template = organizeTemplate(cfg)
+	}
+
+	products, err := db.ListProducts()
+	if err != nil {
+		return nil, err
+	}
+	home, err := homeRoot(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return organize.PlanReorganize(home, template, products)
+}
+
+// ExecuteReorganize makes the moves of a plan returned by PlanReorganize and
+// returns the undo journal. The plan is rejected if the library changed since
+// it was previewed, so only the moves the user saw are made.
+func (a *App) ExecuteReorganize(previewed organize.Plan) (*organize.Journal, error) {
+	plan, err := a.PlanReorganize(previewed.Template)
+	if err != nil {
+		return nil, err
+	}
+	if !plan.Matches(&previewed) {
+		return nil, organize.ErrStalePlan
+	}
+	return organize.Execute(plan, organize.JournalDir())
+}
+
+// UndoReorganize moves product folders back to where they were before a reorganize
+func (a *App) UndoReorganize(id string) error {
+	return organize.Undo(organize.JournalDir(), id)
+}
+
+// ListReorganizeJournals returns past reorganizes, newest first
+func (a *App) ListReorganizeJournals() ([]organize.Journal, error) {
+	return organize.ListJournals(organize.JournalDir())
+}
+
+func organizeTemplate(cfg *config.Config) string {
+	if cfg.OrganizeTemplate == "" {
+		return config.DefaultOrganizeTemplate
+	}
+	return cfg.OrganizeTemplate
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"aslm/config"
+	"aslm/db"
+	"aslm/library"
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// RootStatus is a library root with its current state
+type RootStatus struct {
+	Root     config.Root `json:"root"`
+	State    string      `json:"state"` // "online", "offline" or "disabled"
+	Products int         `json:"products"`
+}
+
+// rootCheckInterval is how often root folders are checked for a drive being
+// plugged in or removed
+const rootCheckInterval = 30 * time.Second
+
+// startRootMonitor re-checks the library roots in the background. When a
+// root goes online or offline the database is updated and a "roots:changed"
+// event is emitted with the new states.
+func (a *App) startRootMonitor() {
+	if a.stopRoots != nil {
+		a.stopRoots()
+		a.stopRoots = nil
+	}
+	a.stopRoots = a.runWorker(func(ctx context.Context) {
+		ticker := time.NewTicker(rootCheckInterval)
+		defer ticker.Stop()
+
+		last := rootStates()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			states := rootStates()
+			if slices.Equal(states, last) {
+				continue
+			}
+			cfg, err := config.LoadConfig()
+			if err != nil {
+				continue
+			}
+			if _, err := syncRoots(cfg); err != nil {
+				fmt.Printf("Error checking library roots: %v\n", err)
+				continue
+			}
+			last = states
+			runtime.EventsEmit(a.ctx, "roots:changed", states)
+		}
+	})
+}
+
+// rootStates returns the current state of every configured root
+func rootStates() []string {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil
+	}
+	states := make([]string, len(cfg.Roots))
+	for i, r := range cfg.Roots {
+		states[i] = library.State(r)
+	}
+	return states
+}
+
+// ListRoots checks every library root and returns it with its state and
+// product count. Products in offline roots are marked offline rather than
+// treated as missing.
+func (a *App) ListRoots() ([]RootStatus, error) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	states, err := syncRoots(cfg)
+	if err != nil {
+		return nil, err
+	}
+	counts, err := db.CountProductsByRoot()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]RootStatus, 0, len(cfg.Roots))
+	for i, r := range cfg.Roots {
+		result = append(result, RootStatus{Root: r, State: states[i], Products: counts[r.ID]})
+	}
+	return result, nil
+}
+
+// SaveRoots replaces the library roots. The first enabled root receives new
+// downloads. A new root whose folder already has a marker (e.g. a drive that
+// was added on another machine sharing the database) keeps the marker's ID so
+// its products are found again; other new roots get a new ID. A missing
+// label defaults to the folder name.
+func (a *App) SaveRoots(roots []config.Root) error {
+	used := make(map[string]bool)
+	for _, r := range roots {
+		used[r.ID] = true
+	}
+
+	seen := make(map[string]bool)
+	for i := range roots {
+		r := &roots[i]
+		r.Path = strings.TrimSpace(r.Path)
+		if r.Path == "" {
+			return fmt.Errorf("library root %d has no path", i+1)
+		}
+		if r.ID == "" {
+			if id, _ := library.ReadMarker(r.Path); id != "" && !used[id] {
+				r.ID = id
+			} else {
+				r.ID = config.NewRootID()
+			}
+			used[r.ID] = true
+		}
+		if seen[r.ID] {
+			return fmt.Errorf("duplicate library root ID: %s", r.ID)
+		}
+		seen[r.ID] = true
+		if strings.TrimSpace(r.Label) == "" {
+			r.Label = filepath.Base(r.Path)
+		}
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	cfg.Roots = roots
+	if err := saveValidConfig(cfg, "roots"); err != nil {
+		return err
+	}
+	if _, err := syncRoots(cfg); err != nil {
+		return err
+	}
+	// Downloads are filed into the first enabled root, which may have changed
+	a.startInbox()
+	return nil
+}
+
+// syncRoots records the current state of every root in the database and
+// returns the states in the order of cfg.Roots
+func syncRoots(cfg *config.Config) ([]string, error) {
+	states, warnings, err := library.Sync(cfg)
+	for _, w := range warnings {
+		fmt.Printf("Library roots: %s\n", w)
+	}
+	return states, err
+}
+
+// homeRoot returns the folder new downloads go to
+func homeRoot(cfg *config.Config) (string, error) {
+	home := cfg.HomeRoot()
+	if home == "" {
+		return "", fmt.Errorf("no library root is enabled")
+	}
+	return home, nil
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"aslm/ai"
+	"aslm/search"
+	"context"
+	"fmt"
+)
+
+// SearchProducts finds products by meaning as well as by text, so
+// "cat-ear hoodie" also finds "猫耳パーカー". Without an AI provider, or when
+// the query can't be embedded (e.g. the daily budget is used up), only
+// full-text search is used.
+func (a *App) SearchProducts(query string, limit int) ([]search.Hit, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), aiTimeout)
+	defer cancel()
+
+	return search.Search(ctx, searchEmbedder(), query, limit)
+}
+
+// RebuildSearchIndex refreshes the full-text index and computes embeddings
+// for new or changed products. It returns the number of products embedded.
+func (a *App) RebuildSearchIndex() (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*aiTimeout)
+	defer cancel()
+
+	return search.Index(ctx, searchEmbedder())
+}
+
+// searchEmbedder returns the configured provider for embeddings, or nil if
+// AI isn't configured
+func searchEmbedder() ai.Embedder {
+	provider, err := aiProvider(ai.TaskEmbedding)
+	if err != nil {
+		fmt.Printf("Semantic search unavailable: %v\n", err)
+		return nil
+	}
+	return provider
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"aslm/config"
+	"aslm/secret"
+	"aslm/server"
+	"fmt"
+)
+
+// APIServerStatus describes the local REST API
+type APIServerStatus struct {
+	Enabled bool   `json:"enabled"`
+	Running bool   `json:"running"`
+	Address string `json:"address"` // e.g. "127.0.0.1:47811"
+	Port    int    `json:"port"`    // Configured port; 0 is the default
+}
+
+// startServer starts the local REST API if it is enabled
+func (a *App) startServer() {
+	cfg, err := config.LoadConfig()
+	if err != nil || !cfg.APIServerEnabled {
+		return
+	}
+	if err := a.restartServer(cfg); err != nil {
+		fmt.Printf("Failed to start API server: %v\n", err)
+	}
+}
+
+// restartServer stops the running API server and starts it again with the
+// current port and token, if enabled
+func (a *App) restartServer(cfg *config.Config) error {
+	if a.server != nil {
+		a.server.Stop()
+		a.server = nil
+	}
+	if !cfg.APIServerEnabled {
+		return nil
+	}
+
+	token, err := server.LoadToken()
+	if err != nil {
+		return fmt.Errorf("failed to load API token: %w", err)
+	}
+	srv := &server.Server{Port: cfg.APIServerPort, Token: token, Search: a.SearchProducts}
+	if err := srv.Start(); err != nil {
+		return err
+	}
+	fmt.Printf("API server listening on http://%s/api/v1\n", srv.Addr())
+	a.server = srv
+	return nil
+}
+
+// GetAPIServerStatus returns whether the local REST API is enabled and running
+func (a *App) GetAPIServerStatus() (*APIServerStatus, error) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	srv := &server.Server{Port: cfg.APIServerPort}
+	return &APIServerStatus{
+		Enabled: cfg.APIServerEnabled,
+		Running: a.server != nil,
+		Address: srv.Addr(),
+		Port:    cfg.APIServerPort,
+	}, nil
+}
+
+// SetAPIServer enables or disables the local REST API and sets its port
+// (0 for the default), restarting it if needed
+func (a *App) SetAPIServer(enabled bool, port int) (*APIServerStatus, error) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	cfg.APIServerEnabled = enabled
+	cfg.APIServerPort = port
+	if err := saveValidConfig(cfg, "apiServerPort"); err != nil {
+		return nil, err
+	}
+	if err := a.restartServer(cfg); err != nil {
+		return nil, err
+	}
+	return a.GetAPIServerStatus()
+}
+
+// GetAPIToken returns the bearer token for the local REST API so it can be
+// copied into other tools. A token is created on first use.
+func (a *App) GetAPIToken() (string, error) {
+	return server.LoadToken()
+}
+
+// RegenerateAPIToken replaces the API token; tools using the old one stop working
+func (a *App) RegenerateAPIToken() (string, error) {
+	token := server.NewToken()
+	if err := secret.Set(secret.APIToken, token); err != nil {
+		return "", err
+	}
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return "", err
+	}
+	return token, a.restartServer(cfg)
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"aslm/ai"
+	"aslm/archive"
+	"aslm/booth"
+	"aslm/db"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// maxPageTextChars limits how much product page text is sent to the model
+	maxPageTextChars = 8000
+	// maxSuggestAssets limits how many asset paths are collected from a product
+	maxSuggestAssets = 2000
+)
+
+// TagSelection is the part of a TagSuggestions the user accepted
+type TagSelection struct {
+	Tags     []string `json:"tags"`
+	Category string   `json:"category"`
+	Avatars  []string `json:"avatars"`
+}
+
+// SuggestTags asks the configured AI provider to propose tags, a category and compatible avatars
+// for a product, based on its Booth page and the assets in its unitypackages.
+// Only the page description is saved (for search); pass the accepted
+// suggestions to ApplyTagSuggestions.
+func (a *App) SuggestTags(path string) (*ai.TagSuggestions, error) {
+	provider, err := aiProvider(ai.TaskTagSuggest)
+	if err != nil {
+		return nil, err
+	}
+	return suggestTags(provider, path)
+}
+
+// TagSuggestionResult is the outcome of SuggestTagsBulk for one path
+type TagSuggestionResult struct {
+	Path        string             `json:"path"`
+	Suggestions *ai.TagSuggestions `json:"suggestions,omitempty"`
+	Error       string             `json:"error,omitempty"`
+}
+
+// SuggestTagsBulk suggests tags for several products. It stops sending
+// requests once the daily AI budget is used up; the remaining paths are
+// returned with the budget error.
+func (a *App) SuggestTagsBulk(paths []string) ([]TagSuggestionResult, error) {
+	provider, err := aiProvider(ai.TaskTagSuggest)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]TagSuggestionResult, 0, len(paths))
+	var budgetErr error
+	for _, path := range paths {
+		result := TagSuggestionResult{Path: path}
+
+		if budgetErr == nil {
+			budgetErr = provider.CheckBudget()
+		}
+		if budgetErr != nil {
+			result.Error = budgetErr.Error()
+			results = append(results, result)
+			continue
+		}
+
+		if result.Suggestions, err = suggestTags(provider, path); err != nil {
+			result.Error = err.Error()
+			if errors.Is(err, ai.ErrBudgetExceeded) {
+				budgetErr = err
+			}
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+func suggestTags(provider ai.Provider, path string) (*ai.TagSuggestions, error) {
+	input := ai.TagSuggestionInput{Name: filepath.Base(path)}
+
+	info, err := db.GetProductInfo(path)
+	if err != nil {
+		return nil, err
+	}
+	if info != nil && info.Url != "" {
+		page, err := booth.FetchItemPage(info.Url, maxPageTextChars)
+		if err != nil {
+			// The file contents alone still give useful suggestions
+			fmt.Printf("Failed to fetch product page for tag suggestions: %v\n", err)
+		} else {
+			input.PageText = page.Title + "\n" + page.Text
+			// Keep the description for search
+			if err := db.SetProductDescription(path, page.Description); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if input.Assets, err = collectAssets(path); err != nil {
+		return nil, err
+	}
+
+	tags, err := db.ListTags()
+	if err != nil {
+		return nil, err
+	}
+	for _, tag := range tags {
+		input.Vocabulary = append(input.Vocabulary, tag.Path)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), aiTimeout)
+	defer cancel()
+
+	return ai.SuggestTags(ctx, provider, input)
+}
+
+// ApplyTagSuggestions adds the accepted tags to a product. The category and
+// avatars are stored as top-level "category/<name>" and "avatar/<name>" tags,
+// so they can be used in reorganize templates as {tag:category} and
+// {tag:avatar}. They are anchored at the top level because "avatar" is also a
+// category and would otherwise resolve to "category/avatar".
+func (a *App) ApplyTagSuggestions(path string, selection TagSelection) error {
+	tags := append([]string{}, selection.Tags...)
+	if selection.Category != "" {
+		tags = append(tags, "/category/"+selection.Category)
+	}
+	for _, avatar := range selection.Avatars {
+		tags = append(tags, "/avatar/"+avatar)
+	}
+	return db.PatchProduct(path, db.ProductPatch{AddTags: tags})
+}
+
+// collectAssets returns the asset paths of every unitypackage in a product:
+// the path itself, unitypackages inside a .zip/.7z, or all of those in a folder
+func collectAssets(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	if info.IsDir() {
+		err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return nil
+			}
+			if strings.EqualFold(filepath.Ext(p), ".unitypackage") || archive.IsArchive(p) {
+				files = append(files, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		files = []string{path}
+	}
+
+	var assets []string
+	for _, file := range files {
+		if len(assets) >= maxSuggestAssets {
+			break
+		}
+
+		if strings.EqualFold(filepath.Ext(file), ".unitypackage") {
+			found, err := archive.UnityPackageAssets(file)
+			if err != nil {
+				fmt.Printf("Skipping %s: %v\n", file, err)
+				continue
+			}
+			assets = append(assets, found...)
+			continue
+		}
+
+		if !archive.IsArchive(file) {
+			continue
+		}
+		listing, err := archive.List(file)
+		if err != nil {
+			fmt.Printf("Skipping %s: %v\n", file, err)
+			continue
+		}
+		for _, name := range listing.UnityPackages {
+			found, err := archive.ArchiveUnityPackageAssets(file, name)
+			if err != nil {
+				fmt.Printf("Skipping %s in %s: %v\n", name, file, err)
+				continue
+			}
+			assets = append(assets, found...)
+		}
+	}
+
+	if len(assets) > maxSuggestAssets {
+		assets = assets[:maxSuggestAssets]
+	}
+	return assets, nil
+}
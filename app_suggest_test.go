@@ -0,0 +1,59 @@
+package main
+
+import (
+	"aslm/config"
+	"aslm/db"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// openTestDB points the data directory at a temporary folder and opens a fresh database
+func openTestDB(t *testing.T) {
+	t.Helper()
+	config.SetDataDir(t.TempDir())
+	if err := db.InitDB(); err != nil {
+		t.Fatalf("InitDB: %v", err)
+	}
+	t.Cleanup(func() { db.DB.Close() })
+}
+
+func TestApplyTagSuggestionsAvatarDoesNotNestUnderCategory(t *testing.T) {
+	openTestDB(t)
+
+	first := filepath.Join(t.TempDir(), "Manuka body")
+	second := filepath.Join(t.TempDir(), "Manuka outfit")
+	for _, path := range []string{first, second} {
+		if err := db.RegisterProduct(path, filepath.Base(path)); err != nil {
+			t.Fatalf("RegisterProduct: %v", err)
+		}
+	}
+
+	app := &App{}
+	// "avatar" is a category, so "category/avatar" exists before any avatar tag
+	if err := app.ApplyTagSuggestions(first, TagSelection{Category: "avatar"}); err != nil {
+		t.Fatalf("ApplyTagSuggestions: %v", err)
+	}
+	if err := app.ApplyTagSuggestions(second, TagSelection{Category: "outfit", Avatars: []string{"マヌカ"}}); err != nil {
+		t.Fatalf("ApplyTagSuggestions: %v", err)
+	}
+
+	info, err := db.GetProductInfo(second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"avatar/マヌカ", "category/outfit"}
+	got := slices.Clone(info.Tags)
+	slices.Sort(got)
+	if !slices.Equal(got, want) {
+		t.Errorf("tags = %v, want %v", got, want)
+	}
+
+	products, err := db.FindProducts(db.ProductQuery{Tags: []string{"avatar"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(products) != 1 || products[0].Path != second {
+		t.Errorf("avatar filter matched %d products, want only %s", len(products), second)
+	}
+}
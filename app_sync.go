@@ -0,0 +1,113 @@
+package main
+
+import (
+	"aslm/catalog"
+	"aslm/config"
+	"aslm/db"
+	"fmt"
+	"strings"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// syncConflictLimit is how many conflict log entries ListSyncConflicts returns
+const syncConflictLimit = 200
+
+// SyncSettings selects the shared folder metadata is synced through
+type SyncSettings struct {
+	Folder string `json:"folder"` // Empty disables sync
+	Name   string `json:"name"`   // Shown to other members; empty uses the host name
+}
+
+// SyncStatus describes the sync folder and the peers seen in it
+type SyncStatus struct {
+	SyncSettings
+	Self  catalog.Peer  `json:"self"`
+	Peers []db.SyncPeer `json:"peers"`
+}
+
+// startSync syncs the configured folder on an interval. Changes made by a
+// sync are reported through the "sync:done" event.
+func (a *App) startSync() {
+	if a.stopSync != nil {
+		a.stopSync()
+		a.stopSync = nil
+	}
+	cfg, err := config.LoadConfig()
+	if err != nil || cfg.SyncFolder == "" {
+		return
+	}
+	self, err := catalog.SelfPeer(cfg)
+	if err != nil {
+		fmt.Printf("Failed to start sync: %v\n", err)
+		return
+	}
+
+	syncer := &catalog.Syncer{
+		Folder: cfg.SyncFolder,
+		Self:   self,
+		OnSync: func(result *catalog.SyncResult) {
+			runtime.EventsEmit(a.ctx, "sync:done", result)
+		},
+	}
+	a.stopSync = a.runWorker(syncer.Run)
+}
+
+// SyncNow merges the catalogs of the other members and publishes this one
+func (a *App) SyncNow() (*catalog.SyncResult, error) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	if cfg.SyncFolder == "" {
+		return nil, fmt.Errorf("sync folder not configured")
+	}
+	self, err := catalog.SelfPeer(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return catalog.Sync(cfg.SyncFolder, self)
+}
+
+// GetSyncStatus returns the sync settings and the peers synced so far
+func (a *App) GetSyncStatus() (*SyncStatus, error) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	status := &SyncStatus{SyncSettings: SyncSettings{Folder: cfg.SyncFolder, Name: cfg.SyncName}}
+	if cfg.SyncFolder != "" {
+		if status.Self, err = catalog.SelfPeer(cfg); err != nil {
+			return nil, err
+		}
+	}
+	if status.Peers, err = db.ListSyncPeers(); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// SaveSyncSettings saves the sync folder and name and restarts syncing
+func (a *App) SaveSyncSettings(settings SyncSettings) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+	cfg.SyncFolder = strings.TrimSpace(settings.Folder)
+	cfg.SyncName = strings.TrimSpace(settings.Name)
+	if err := saveValidConfig(cfg, "syncFolder"); err != nil {
+		return err
+	}
+	a.startSync()
+	return nil
+}
+
+// ListSyncConflicts returns the newest entries of the sync conflict log
+func (a *App) ListSyncConflicts() ([]db.SyncConflict, error) {
+	return db.ListSyncConflicts(syncConflictLimit)
+}
+
+// ClearSyncConflicts empties the sync conflict log
+func (a *App) ClearSyncConflicts() error {
+	return db.ClearSyncConflicts()
+}
@@ -0,0 +1,48 @@
+package main
+
+import "aslm/db"
+
+// ListTags returns all tags with their paths, aliases, colors and usage counts
+func (a *App) ListTags() ([]db.TagInfo, error) {
+	return db.ListTags()
+}
+
+// CreateTag creates a tag; "parent/child" creates the parent too
+func (a *App) CreateTag(name string) (int64, error) {
+	return db.CreateTag(name)
+}
+
+// RenameTag renames a tag on every product at once
+func (a *App) RenameTag(tagID int64, newName string) error {
+	return db.RenameTag(tagID, newName)
+}
+
+// MergeTags merges source into target; the source name is kept as an alias
+func (a *App) MergeTags(sourceID int64, targetID int64) error {
+	return db.MergeTags(sourceID, targetID)
+}
+
+// DeleteTag removes a tag from every product
+func (a *App) DeleteTag(tagID int64) error {
+	return db.DeleteTag(tagID)
+}
+
+// SetTagParent moves a tag below another tag (0 for top level)
+func (a *App) SetTagParent(tagID int64, parentID int64) error {
+	return db.SetTagParent(tagID, parentID)
+}
+
+// SetTagStyle sets the color and icon shown for a tag
+func (a *App) SetTagStyle(tagID int64, color string, icon string) error {
+	return db.SetTagStyle(tagID, color, icon)
+}
+
+// AddTagAlias makes alias resolve to the tag when tagging products
+func (a *App) AddTagAlias(tagID int64, alias string) error {
+	return db.AddTagAlias(tagID, alias)
+}
+
+// RemoveTagAlias deletes an alias
+func (a *App) RemoveTagAlias(alias string) error {
+	return db.RemoveTagAlias(alias)
+}
@@ -0,0 +1,236 @@
+package archive
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bodgit/sevenzip"
+)
+
+// Entry kinds reported in a Listing
+const (
+	KindFile         = "file"
+	KindDir          = "dir"
+	KindUnityPackage = "unitypackage"
+	KindReadme       = "readme"
+	KindArchive      = "archive"
+)
+
+// Entry represents a single file or directory inside an archive
+type Entry struct {
+	Name           string    `json:"name"` // Slash-separated path inside the archive, decoded to UTF-8
+	Size           int64     `json:"size"`
+	CompressedSize int64     `json:"compressedSize"`
+	IsDir          bool      `json:"isDir"`
+	Modified       time.Time `json:"modified"`
+	Kind           string    `json:"kind"`
+}
+
+// Listing is the table of contents of an archive
+type Listing struct {
+	Path           string   `json:"path"`
+	Format         string   `json:"format"` // "zip" or "7z"
+	Entries        []Entry  `json:"entries"`
+	UnityPackages  []string `json:"unityPackages"`
+	Readmes        []string `json:"readmes"`
+	NestedArchives []string `json:"nestedArchives"`
+	FileCount      int      `json:"fileCount"`
+	TotalSize      int64    `json:"totalSize"`
+}
+
+// readmeKeywords are lowercase substrings that identify readme-like files
+var readmeKeywords = []string{"readme", "read me", "read_me", "りどみ", "リドミ", "説明", "はじめに", "利用規約", "license", "licence", "terms"}
+
+// readmeExtensions are the file types shown as readmes
+var readmeExtensions = map[string]bool{"": true, ".txt": true, ".md": true, ".pdf": true, ".html": true, ".htm": true, ".url": true}
+
+// IsArchive reports whether the path has an extension this package can read
+func IsArchive(p string) bool {
+	return formatOf(p) != ""
+}
+
+// List returns the contents of a .zip or .7z archive without extracting it
+func List(archivePath string) (*Listing, error) {
+	listing := &Listing{Path: archivePath, Format: formatOf(archivePath)}
+
+	switch listing.Format {
+	case "zip":
+		r, err := zip.OpenReader(archivePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open zip: %w", err)
+		}
+		defer r.Close()
+
+		for _, f := range r.File {
+			listing.Entries = append(listing.Entries, Entry{
+				Name:           zipEntryName(f),
+				Size:           int64(f.UncompressedSize64),
+				CompressedSize: int64(f.CompressedSize64),
+				IsDir:          f.FileInfo().IsDir(),
+				Modified:       f.Modified,
+			})
+		}
+	case "7z":
+		r, err := sevenzip.OpenReader(archivePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open 7z: %w", err)
+		}
+		defer r.Close()
+
+		for _, f := range r.File {
+			// 7z stores names as UTF-16, so no charset guessing is needed
+			listing.Entries = append(listing.Entries, Entry{
+				Name:     strings.ReplaceAll(f.Name, "\\", "/"),
+				Size:     int64(f.UncompressedSize),
+				IsDir:    f.FileInfo().IsDir(),
+				Modified: f.Modified,
+			})
+		}
+	default:
+		return nil, fmt.Errorf("unsupported archive format: %s", filepath.Ext(archivePath))
+	}
+
+	for i := range listing.Entries {
+		e := &listing.Entries[i]
+		e.Kind = classify(e)
+
+		if e.IsDir {
+			continue
+		}
+		listing.FileCount++
+		listing.TotalSize += e.Size
+
+		switch e.Kind {
+		case KindUnityPackage:
+			listing.UnityPackages = append(listing.UnityPackages, e.Name)
+		case KindReadme:
+			listing.Readmes = append(listing.Readmes, e.Name)
+		case KindArchive:
+			listing.NestedArchives = append(listing.NestedArchives, e.Name)
+		}
+	}
+
+	sort.Slice(listing.Entries, func(i, j int) bool {
+		return listing.Entries[i].Name < listing.Entries[j].Name
+	})
+
+	return listing, nil
+}
+
+// ReadEntry returns the text of a single file inside an archive, decoded to UTF-8.
+// At most maxBytes bytes are read; it is meant for previewing readme files.
+func ReadEntry(archivePath string, name string, maxBytes int64) (string, error) {
+	rc, err := openEntry(archivePath, name)
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, maxBytes))
+	if err != nil {
+		return "", err
+	}
+	return decodeText(data), nil
+}
+
+// entryReader closes both the entry and the archive it was opened from
+type entryReader struct {
+	io.ReadCloser
+	archive io.Closer
+}
+
+func (r *entryReader) Close() error {
+	r.ReadCloser.Close()
+	return r.archive.Close()
+}
+
+// openEntry opens a single file inside an archive for reading
+func openEntry(archivePath string, name string) (io.ReadCloser, error) {
+	switch formatOf(archivePath) {
+	case "zip":
+		r, err := zip.OpenReader(archivePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open zip: %w", err)
+		}
+		for _, f := range r.File {
+			if zipEntryName(f) == name {
+				rc, err := f.Open()
+				if err != nil {
+					r.Close()
+					return nil, err
+				}
+				return &entryReader{rc, r}, nil
+			}
+		}
+		r.Close()
+	case "7z":
+		r, err := sevenzip.OpenReader(archivePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open 7z: %w", err)
+		}
+		for _, f := range r.File {
+			if strings.ReplaceAll(f.Name, "\\", "/") == name {
+				rc, err := f.Open()
+				if err != nil {
+					r.Close()
+					return nil, err
+				}
+				return &entryReader{rc, r}, nil
+			}
+		}
+		r.Close()
+	default:
+		return nil, fmt.Errorf("unsupported archive format: %s", filepath.Ext(archivePath))
+	}
+
+	return nil, fmt.Errorf("entry not found in archive: %s", name)
+}
+
+// zipEntryName returns the UTF-8 name of a zip entry, preferring the
+// Unicode Path extra field and falling back to Shift_JIS decoding
+func zipEntryName(f *zip.File) string {
+	if name, ok := unicodePathFromExtra(f.Extra); ok {
+		return name
+	}
+	return decodeName(f.Name)
+}
+
+func formatOf(p string) string {
+	switch strings.ToLower(filepath.Ext(p)) {
+	case ".zip":
+		return "zip"
+	case ".7z":
+		return "7z"
+	}
+	return ""
+}
+
+func classify(e *Entry) string {
+	if e.IsDir {
+		return KindDir
+	}
+
+	base := strings.ToLower(path.Base(e.Name))
+	ext := path.Ext(base)
+
+	if ext == ".unitypackage" {
+		return KindUnityPackage
+	}
+	if formatOf(base) != "" {
+		return KindArchive
+	}
+	if readmeExtensions[ext] {
+		for _, keyword := range readmeKeywords {
+			if strings.Contains(base, keyword) {
+				return KindReadme
+			}
+		}
+	}
+	return KindFile
+}
@@ -0,0 +1,90 @@
+package archive
+
+import (
+	"bytes"
+	"encoding/binary"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// infoZipUnicodePathID is the Info-ZIP Unicode Path extra field (0x7075)
+const infoZipUnicodePathID = 0x7075
+
+// decodeName converts a raw archive entry name to UTF-8.
+// Names that are already valid UTF-8 are returned as is; anything else is
+// treated as Shift_JIS (CP932), which is what Japanese Windows zips use.
+func decodeName(raw string) string {
+	if utf8.ValidString(raw) {
+		return raw
+	}
+	decoded, err := japanese.ShiftJIS.NewDecoder().String(raw)
+	if err != nil {
+		return raw
+	}
+	return decoded
+}
+
+// unicodePathFromExtra returns the UTF-8 name stored in the Info-ZIP Unicode
+// Path extra field, if present. Some archivers write it alongside a
+// Shift_JIS primary name.
+func unicodePathFromExtra(extra []byte) (string, bool) {
+	for len(extra) >= 4 {
+		id := binary.LittleEndian.Uint16(extra[0:2])
+		size := int(binary.LittleEndian.Uint16(extra[2:4]))
+		if len(extra) < 4+size {
+			return "", false
+		}
+		data := extra[4 : 4+size]
+		// version(1) + crc32(4) + name
+		if id == infoZipUnicodePathID && size > 5 && data[0] == 1 {
+			name := string(data[5:])
+			if utf8.ValidString(name) {
+				return name, true
+			}
+		}
+		extra = extra[4+size:]
+	}
+	return "", false
+}
+
+// decodeText converts the contents of a text file (e.g. a readme) to UTF-8,
+// handling BOMs, UTF-16 and Shift_JIS.
+func decodeText(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xEF, 0xBB, 0xBF}):
+		return string(data[3:])
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}), bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		decoded, err := unicode.UTF16(unicode.LittleEndian, unicode.ExpectBOM).NewDecoder().Bytes(data)
+		if err == nil {
+			return string(decoded)
+		}
+	}
+
+	if utf8.Valid(data) {
+		return string(data)
+	}
+	// A truncated preview may end in the middle of a multi-byte character
+	if trimmed := trimPartialRune(data); utf8.Valid(trimmed) {
+		return string(trimmed)
+	}
+	decoded, err := japanese.ShiftJIS.NewDecoder().Bytes(data)
+	if err != nil {
+		return string(data)
+	}
+	return string(decoded)
+}
+
+// trimPartialRune drops an incomplete UTF-8 sequence from the end of data
+func trimPartialRune(data []byte) []byte {
+	for i := 1; i <= utf8.UTFMax && i <= len(data); i++ {
+		if utf8.RuneStart(data[len(data)-i]) {
+			if !utf8.FullRune(data[len(data)-i:]) {
+				return data[:len(data)-i]
+			}
+			break
+		}
+	}
+	return data
+}
@@ -0,0 +1,292 @@
+package archive
+
+import (
+	"archive/zip"
+	"aslm/fsutil"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Default limits used when ExtractOptions leaves them at zero
+const (
+	DefaultMaxTotalSize = 32 << 30 // 32 GiB
+	DefaultMaxFiles     = 200000
+	DefaultMaxRatio     = 200
+)
+
+// ratioCheckMinSize is the entry size below which the compression ratio is not checked;
+// small text files legitimately compress very well
+const ratioCheckMinSize = 1 << 20
+
+// progressInterval is how often (in bytes) progress is reported while copying a large entry
+const progressInterval = 4 << 20
+
+var (
+	// ErrUnsafePath is returned when an entry would be written outside the destination
+	ErrUnsafePath = errors.New("archive entry has an unsafe path")
+	// ErrTooLarge is returned when an archive exceeds the configured size, file count or compression ratio
+	ErrTooLarge = errors.New("archive exceeds extraction limits")
+)
+
+// ExtractOptions controls ExtractZip
+type ExtractOptions struct {
+	MaxTotalSize    int64   // Maximum total uncompressed size in bytes
+	MaxFiles        int     // Maximum number of entries
+	MaxRatio        float64 // Maximum uncompressed/compressed ratio for a single entry
+	StripSingleRoot bool    // If every entry is inside one top-level folder, extract its contents directly
+	Progress        func(Progress)
+}
+
+// Progress reports extraction progress
+type Progress struct {
+	Archive    string `json:"archive"`
+	Entry      string `json:"entry"`
+	BytesDone  int64  `json:"bytesDone"`
+	BytesTotal int64  `json:"bytesTotal"`
+	FilesDone  int    `json:"filesDone"`
+	FilesTotal int    `json:"filesTotal"`
+}
+
+// ExtractZip extracts a zip archive into dest, which must not exist yet.
+// Entry names are decoded from Shift_JIS where needed. The archive is first
+// extracted into a temporary sibling folder which is renamed to dest only
+// when everything succeeded, so a failed extraction never leaves a
+// half-populated product folder behind.
+func ExtractZip(src string, dest string, opts ExtractOptions) error {
+	if opts.MaxTotalSize <= 0 {
+		opts.MaxTotalSize = DefaultMaxTotalSize
+	}
+	if opts.MaxFiles <= 0 {
+		opts.MaxFiles = DefaultMaxFiles
+	}
+	if opts.MaxRatio <= 0 {
+		opts.MaxRatio = DefaultMaxRatio
+	}
+
+	if _, err := os.Lstat(dest); err == nil {
+		return fmt.Errorf("destination already exists: %s", dest)
+	}
+
+	r, err := zip.OpenReader(src)
+	if err != nil {
+		return fmt.Errorf("failed to open zip: %w", err)
+	}
+	defer r.Close()
+
+	if len(r.File) > opts.MaxFiles {
+		return fmt.Errorf("%w: %d entries (limit %d)", ErrTooLarge, len(r.File), opts.MaxFiles)
+	}
+
+	// Validate every entry before writing anything
+	names := make([]string, len(r.File))
+	var total int64
+	for i, f := range r.File {
+		name, err := safeEntryPath(zipEntryName(f))
+		if err != nil {
+			return err
+		}
+		if name == "__MACOSX" || strings.HasPrefix(name, "__MACOSX/") {
+			// Resource forks added by macOS Finder are never useful in the library
+			name = ""
+		}
+		names[i] = name
+
+		size := int64(f.UncompressedSize64)
+		if size < 0 || total+size > opts.MaxTotalSize {
+			return fmt.Errorf("%w: more than %d bytes uncompressed", ErrTooLarge, opts.MaxTotalSize)
+		}
+		total += size
+
+		if size > ratioCheckMinSize {
+			if f.CompressedSize64 == 0 || float64(size)/float64(f.CompressedSize64) > opts.MaxRatio {
+				return fmt.Errorf("%w: suspicious compression ratio for %s", ErrTooLarge, name)
+			}
+		}
+	}
+
+	if opts.StripSingleRoot {
+		names = stripSingleRoot(names, r.File)
+	}
+
+	tmp, err := os.MkdirTemp(filepath.Dir(dest), "."+filepath.Base(dest)+".partial-")
+	if err != nil {
+		return err
+	}
+	succeeded := false
+	defer func() {
+		if !succeeded {
+			os.RemoveAll(tmp)
+		}
+	}()
+
+	progress := Progress{Archive: src, BytesTotal: total, FilesTotal: len(r.File)}
+	report := func() {
+		if opts.Progress != nil {
+			opts.Progress(progress)
+		}
+	}
+
+	for i, f := range r.File {
+		name := names[i]
+		progress.Entry = name
+
+		if name == "" || f.Mode()&os.ModeSymlink != 0 {
+			// Skipped entries, the stripped root itself, or a symlink, which is never followed
+			progress.FilesDone++
+			continue
+		}
+
+		target := filepath.Join(tmp, filepath.FromSlash(name))
+		if !fsutil.IsWithin(tmp, target) {
+			return fmt.Errorf("%w: %s", ErrUnsafePath, name)
+		}
+
+		if f.FileInfo().IsDir() {
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		} else {
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			if err := extractFile(f, target, &progress, report); err != nil {
+				return fmt.Errorf("failed to extract %s: %w", name, err)
+			}
+		}
+
+		progress.FilesDone++
+		report()
+	}
+
+	if err := os.Rename(tmp, dest); err != nil {
+		return err
+	}
+	succeeded = true
+	return nil
+}
+
+// extractFile copies a single entry, refusing to write more than the size
+// declared in the header (a common zip bomb trick)
+func extractFile(f *zip.File, target string, progress *Progress, report func()) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	declared := int64(f.UncompressedSize64)
+	var written int64
+	buf := make([]byte, 256*1024)
+	limited := io.LimitReader(rc, declared+1)
+	sinceReport := int64(0)
+
+	for {
+		n, readErr := limited.Read(buf)
+		if n > 0 {
+			written += int64(n)
+			if written > declared {
+				out.Close()
+				return fmt.Errorf("%w: entry is larger than declared", ErrTooLarge)
+			}
+			if _, err := out.Write(buf[:n]); err != nil {
+				out.Close()
+				return err
+			}
+			progress.BytesDone += int64(n)
+			sinceReport += int64(n)
+			if sinceReport >= progressInterval {
+				sinceReport = 0
+				report()
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			out.Close()
+			return readErr
+		}
+	}
+
+	if err := out.Close(); err != nil {
+		return err
+	}
+	if !f.Modified.IsZero() {
+		os.Chtimes(target, f.Modified, f.Modified)
+	}
+	return nil
+}
+
+// safeEntryPath normalizes an entry name and rejects absolute paths and
+// anything that would escape the destination folder (zip-slip). Colons
+// inside a name (e.g. a time in a file name) are replaced, since Windows
+// doesn't allow them.
+func safeEntryPath(name string) (string, error) {
+	name = strings.ReplaceAll(name, "\\", "/")
+	if strings.HasPrefix(name, "/") || filepath.VolumeName(name) != "" || hasDrivePrefix(name) {
+		return "", fmt.Errorf("%w: %s", ErrUnsafePath, name)
+	}
+	name = strings.ReplaceAll(name, ":", "_")
+
+	cleaned := path.Clean(name)
+	if cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("%w: %s", ErrUnsafePath, name)
+	}
+	if cleaned == "." {
+		return "", nil
+	}
+	return cleaned, nil
+}
+
+// hasDrivePrefix reports whether name starts with a drive letter such as
+// "C:", which filepath.VolumeName only detects on Windows
+func hasDrivePrefix(name string) bool {
+	if len(name) < 2 || name[1] != ':' {
+		return false
+	}
+	c := name[0]
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+// stripSingleRoot removes a common top-level folder from all names, if there is one
+func stripSingleRoot(names []string, files []*zip.File) []string {
+	root := ""
+	for i, name := range names {
+		if name == "" {
+			continue
+		}
+		first, _, found := strings.Cut(name, "/")
+		if !found && !files[i].FileInfo().IsDir() {
+			// A file at the top level means there is no single root folder
+			return names
+		}
+		if root == "" {
+			root = first
+		} else if root != first {
+			return names
+		}
+	}
+	if root == "" {
+		return names
+	}
+
+	stripped := make([]string, len(names))
+	for i, name := range names {
+		if name == root {
+			stripped[i] = ""
+			continue
+		}
+		stripped[i] = strings.TrimPrefix(name, root+"/")
+	}
+	return stripped
+}
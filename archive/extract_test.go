@@ -0,0 +1,41 @@
+package archive
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSafeEntryPath(t *testing.T) {
+	cases := []struct {
+		name string
+		want string
+		err  bool
+	}{
+		{"Hat/Hat.unitypackage", "Hat/Hat.unitypackage", false},
+		{`Hat\Textures\hat.png`, "Hat/Textures/hat.png", false},
+		{"Hat/./readme.txt", "Hat/readme.txt", false},
+		{"Ver1.0：更新/readme.txt", "Ver1.0：更新/readme.txt", false},
+		{"log 12:30:00.txt", "log 12_30_00.txt", false},
+		{"Hat/Ver1.0:fix/hat.fbx", "Hat/Ver1.0_fix/hat.fbx", false},
+		{"./", "", false},
+		{"/etc/passwd", "", true},
+		{`\\server\share\x`, "", true},
+		{"C:/Windows/x.dll", "", true},
+		{`c:\x.dll`, "", true},
+		{"C:x.dll", "", true},
+		{"../x", "", true},
+		{"Hat/../../x", "", true},
+	}
+	for _, tc := range cases {
+		got, err := safeEntryPath(tc.name)
+		if tc.err {
+			if !errors.Is(err, ErrUnsafePath) {
+				t.Errorf("safeEntryPath(%q) = %q, %v; want ErrUnsafePath", tc.name, got, err)
+			}
+			continue
+		}
+		if err != nil || got != tc.want {
+			t.Errorf("safeEntryPath(%q) = %q, %v; want %q", tc.name, got, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package archive
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+// UnityPackageAssets returns the asset paths (e.g. "Assets/Shop/Outfit/Top.fbx")
+// contained in a .unitypackage file, sorted
+func UnityPackageAssets(packagePath string) ([]string, error) {
+	f, err := os.Open(packagePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return readUnityPackageAssets(f)
+}
+
+// ArchiveUnityPackageAssets returns the asset paths of a .unitypackage
+// stored inside a .zip or .7z archive, without extracting it
+func ArchiveUnityPackageAssets(archivePath string, name string) ([]string, error) {
+	rc, err := openEntry(archivePath, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	return readUnityPackageAssets(rc)
+}
+
+// readUnityPackageAssets reads a unitypackage stream. A unitypackage is a
+// gzipped tar with one "<guid>/" directory per asset; the asset path is the
+// first line of "<guid>/pathname".
+func readUnityPackageAssets(r io.Reader) ([]string, error) {
+	gz, err := gzip.NewReader(bufio.NewReader(r))
+	if err != nil {
+		return nil, fmt.Errorf("not a unitypackage: %w", err)
+	}
+	defer gz.Close()
+
+	var assets []string
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read unitypackage: %w", err)
+		}
+
+		if header.Typeflag != tar.TypeReg || path.Base(header.Name) != "pathname" {
+			continue
+		}
+
+		data, err := io.ReadAll(io.LimitReader(tr, 4096))
+		if err != nil {
+			return nil, err
+		}
+		assetPath, _, _ := strings.Cut(string(data), "\n")
+		if assetPath = strings.TrimSpace(assetPath); assetPath != "" {
+			assets = append(assets, assetPath)
+		}
+	}
+
+	sort.Strings(assets)
+	return assets, nil
+}
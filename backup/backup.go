@@ -0,0 +1,253 @@
+// Package backup keeps rotated copies of the database in the data directory
+// and restores them.
+package backup
+
+import (
+	"aslm/config"
+	"aslm/db"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Defaults used when the config leaves the interval or count at 0
+const (
+	DefaultInterval = 24 * time.Hour
+	DefaultKeep     = 7
+)
+
+// Backup files are named aslm-<time>.db so that names sort by age
+const (
+	prefix     = "aslm-"
+	suffix     = ".db"
+	timeFormat = "20060102-150405"
+)
+
+// checkInterval is how often the scheduler looks at the age of the newest backup
+const checkInterval = time.Hour
+
+// Info describes a backup file
+type Info struct {
+	Name      string    `json:"name"`
+	Path      string    `json:"path"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Dir returns the folder backups are written to
+func Dir() string {
+	return filepath.Join(config.DataDir(), "backups")
+}
+
+// Create backs up the database and deletes all but the newest keep backups
+// (0 keeps them all). A database that fails the quick check is not backed
+// up, so rotation never replaces good backups with corrupt ones.
+func Create(keep int) (*Info, error) {
+	report, err := db.CheckIntegrity(false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check database: %w", err)
+	}
+	if !report.OK {
+		return nil, fmt.Errorf("database failed the integrity check; not backing it up")
+	}
+
+	if err := os.MkdirAll(Dir(), 0755); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	name := prefix + now.Format(timeFormat) + suffix
+	path := filepath.Join(Dir(), name)
+	// Two backups within a second (e.g. a restore right after a manual backup)
+	for i := 2; exists(path); i++ {
+		name = fmt.Sprintf("%s%s-%d%s", prefix, now.Format(timeFormat), i, suffix)
+		path = filepath.Join(Dir(), name)
+	}
+
+	if err := db.Backup(path); err != nil {
+		os.Remove(path)
+		return nil, fmt.Errorf("failed to back up database: %w", err)
+	}
+	if keep > 0 {
+		if err := Rotate(keep); err != nil {
+			fmt.Printf("Backup rotation failed: %v\n", err)
+		}
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Info{Name: name, Path: path, Size: info.Size(), CreatedAt: now}, nil
+}
+
+// List returns the backups, newest first
+func List() ([]Info, error) {
+	entries, err := os.ReadDir(Dir())
+	if os.IsNotExist(err) {
+		return []Info{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	backups := []Info{}
+	var seqs []int
+	for _, e := range entries {
+		created, seq, ok := parseName(e.Name())
+		if !ok || e.IsDir() {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		backups = append(backups, Info{
+			Name:      e.Name(),
+			Path:      filepath.Join(Dir(), e.Name()),
+			Size:      info.Size(),
+			CreatedAt: created,
+		})
+		seqs = append(seqs, seq)
+	}
+	order := make([]int, len(backups))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool {
+		a, b := order[i], order[j]
+		if !backups[a].CreatedAt.Equal(backups[b].CreatedAt) {
+			return backups[a].CreatedAt.After(backups[b].CreatedAt)
+		}
+		return seqs[a] > seqs[b]
+	})
+	sorted := make([]Info, len(backups))
+	for i, k := range order {
+		sorted[i] = backups[k]
+	}
+	return sorted, nil
+}
+
+// Rotate deletes all but the newest keep backups
+func Rotate(keep int) error {
+	backups, err := List()
+	if err != nil {
+		return err
+	}
+	for i := keep; i < len(backups); i++ {
+		if err := os.Remove(backups[i].Path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Restore replaces the database with the named backup. The current database
+// is backed up first (without rotation) so a restore can be undone; if it is
+// too damaged to back up, it is restored over anyway.
+func Restore(name string) error {
+	if name != filepath.Base(name) {
+		return fmt.Errorf("invalid backup name: %s", name)
+	}
+	if _, _, ok := parseName(name); !ok {
+		return fmt.Errorf("invalid backup name: %s", name)
+	}
+	path := filepath.Join(Dir(), name)
+	if !exists(path) {
+		return fmt.Errorf("backup not found: %s", name)
+	}
+
+	if err := db.CheckFile(path); err != nil {
+		return err
+	}
+
+	if info, err := Create(0); err != nil {
+		fmt.Printf("Could not back up the current database before restoring: %v\n", err)
+	} else {
+		fmt.Printf("Current database saved as %s\n", info.Name)
+	}
+	return db.Restore(path)
+}
+
+// Scheduler creates a backup whenever the newest one is older than Interval
+type Scheduler struct {
+	Interval time.Duration
+	Keep     int
+}
+
+// Run checks for due backups until ctx is cancelled. The age of the newest
+// backup is used rather than a timer, so restarting the app doesn't delay or
+// repeat backups.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.backupIfDue(); err != nil {
+			fmt.Printf("Scheduled backup failed: %v\n", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) backupIfDue() error {
+	backups, err := List()
+	if err != nil {
+		return err
+	}
+	if len(backups) > 0 && time.Since(backups[0].CreatedAt) < s.Interval {
+		return nil
+	}
+	info, err := Create(s.Keep)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Database backed up to %s\n", info.Path)
+	return nil
+}
+
+// Settings returns the interval and number of backups kept from the config
+func Settings(cfg *config.Config) (time.Duration, int) {
+	interval := DefaultInterval
+	if cfg.BackupInterval > 0 {
+		interval = time.Duration(cfg.BackupInterval) * time.Hour
+	}
+	keep := DefaultKeep
+	if cfg.BackupKeep > 0 {
+		keep = cfg.BackupKeep
+	}
+	return interval, keep
+}
+
+// parseName returns the creation time encoded in a backup file name and the
+// sequence number of backups made within the same second (1 for the first)
+func parseName(name string) (time.Time, int, bool) {
+	if !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, suffix) {
+		return time.Time{}, 0, false
+	}
+	stamp := strings.TrimSuffix(strings.TrimPrefix(name, prefix), suffix)
+	seq := 1
+	if len(stamp) > len(timeFormat) {
+		n, err := strconv.Atoi(strings.TrimPrefix(stamp[len(timeFormat):], "-"))
+		if err != nil {
+			return time.Time{}, 0, false
+		}
+		stamp, seq = stamp[:len(timeFormat)], n
+	}
+	t, err := time.ParseInLocation(timeFormat, stamp, time.Local)
+	return t, seq, err == nil
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
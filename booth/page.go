@@ -0,0 +1,105 @@
+package booth
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ItemPage is the readable content of a Booth product page
+type ItemPage struct {
+	URL         string
+	Title       string
+	Description string
+	Text        string // Visible page text with markup removed
+}
+
+var (
+	scriptPattern = regexp.MustCompile(`(?is)<(script|style|noscript|svg)[^>]*>.*?</(script|style|noscript|svg)>`)
+	blockPattern  = regexp.MustCompile(`(?i)<(br|/p|/div|/li|/h[1-6]|/tr)[^>]*>`)
+	tagPattern    = regexp.MustCompile(`<[^>]+>`)
+	blankPattern  = regexp.MustCompile(`[ \t\r\f\v]+`)
+	linesPattern  = regexp.MustCompile(`\n\s*\n+`)
+)
+
+// FetchItemPage downloads a Booth product page and returns its title,
+// description and visible text (truncated to maxChars characters)
+func FetchItemPage(productURL string, maxChars int) (*ItemPage, error) {
+	if !strings.Contains(productURL, "booth.pm/") || !strings.Contains(productURL, "/items/") {
+		return nil, fmt.Errorf("invalid Booth product URL")
+	}
+
+	body, err := fetchPage(productURL)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &ItemPage{
+		URL:         productURL,
+		Title:       metaContent(body, "og:title"),
+		Description: metaContent(body, "og:description"),
+		Text:        HTMLToText(body),
+	}
+	if runes := []rune(page.Text); maxChars > 0 && len(runes) > maxChars {
+		page.Text = string(runes[:maxChars])
+	}
+	return page, nil
+}
+
+// HTMLToText strips scripts, styles and tags from an HTML document and
+// collapses whitespace, keeping line breaks between blocks
+func HTMLToText(doc string) string {
+	text := scriptPattern.ReplaceAllString(doc, " ")
+	text = blockPattern.ReplaceAllString(text, "\n")
+	text = tagPattern.ReplaceAllString(text, " ")
+	text = html.UnescapeString(text)
+	text = blankPattern.ReplaceAllString(text, " ")
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	text = linesPattern.ReplaceAllString(strings.Join(lines, "\n"), "\n")
+	return strings.TrimSpace(text)
+}
+
+func metaContent(doc string, property string) string {
+	pattern := regexp.MustCompile(`<meta[^>]+(?:property|name)="` + regexp.QuoteMeta(property) + `"[^>]+content="([^"]*)"`)
+	if m := pattern.FindStringSubmatch(doc); len(m) > 1 {
+		return html.UnescapeString(strings.TrimSpace(m[1]))
+	}
+	return ""
+}
+
+// fetchPage downloads a Booth page with a browser User-Agent
+func fetchPage(pageURL string) (string, error) {
+	client := &http.Client{
+		Timeout: 10 * time.Second,
+	}
+
+	req, err := http.NewRequest("GET", pageURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch page: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+	return string(body), nil
+}
@@ -53,81 +53,26 @@ func SearchBooth(query string) (*BoothInfo, error) {
 		cleanQuery = query // Fallback to original if cleaning removed everything
 	}
 
-	// URL encode the query and construct search URL
-	searchURL := fmt.Sprintf("https://booth.pm/ja/search/%s", url.QueryEscape(cleanQuery))
-
-	// Create HTTP client with timeout
-	client := &http.Client{
-		Timeout: 10 * time.Second,
-	}
-
-	// Create request with proper headers
-	req, err := http.NewRequest("GET", searchURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	// Set User-Agent to avoid being blocked
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
-
-	// Execute request
-	resp, err := client.Do(req)
+	page, err := FetchSearchPage(cleanQuery)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch search page: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
-	}
-
-	// Read response body
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
-	}
-
-	html := string(body)
-
-	// Extract first product URL
-	// Booth product URLs follow the pattern: https://booth.pm/ja/items/XXXXXXX or https://USERNAME.booth.pm/items/XXXXXXX
-	productURLPattern := regexp.MustCompile(`https://[a-zA-Z0-9_-]+\.booth\.pm/items/\d+`)
-	productURLs := productURLPattern.FindAllString(html, -1)
-
-	if len(productURLs) == 0 {
+	cards := ParseSearchResults(page)
+	if len(cards) == 0 {
 		return nil, fmt.Errorf("no products found for query: %s", query)
 	}
+	card := cards[0]
 
-	productURL := productURLs[0]
-
-	// Try to infer shop name from product URL subdomain (e.g. username.booth.pm)
-	var shopName string
-	if u, err := url.Parse(productURL); err == nil {
-		host := u.Hostname()
-		// host might be like username.booth.pm
-		parts := strings.Split(host, ".")
-		if len(parts) >= 3 {
-			shopName = parts[0]
-		}
-	}
-
-	// Extract thumbnail image URL
-	// Look for image URL near the product URL
-	// Booth uses data-src or src attributes for images
-	imageURLPattern := regexp.MustCompile(`https://booth\.pximg\.net/[a-zA-Z0-9/._-]+\.(?:jpg|jpeg|png|webp)`)
-	imageURLs := imageURLPattern.FindAllString(html, -1)
-
-	var imageURL string
-	if len(imageURLs) > 0 {
-		// Get the first thumbnail (usually the main product image)
-		imageURL = imageURLs[0]
-		// Remove size suffix and get original or larger size
-		imageURL = regexp.MustCompile(`/c/\d+x\d+_[a-z0-9]+/`).ReplaceAllString(imageURL, "/")
+	// Prefer the shop's display name; fall back to its subdomain (e.g. username.booth.pm)
+	shopName := card.Shop
+	if shopName == "" {
+		shopName = card.ShopID
 	}
 
 	return &BoothInfo{
-		ProductURL: productURL,
-		ImageURL:   imageURL,
+		ProductURL: card.URL,
+		ImageURL:   card.ImageURL,
 		ShopName:   shopName,
 	}, nil
 }
@@ -0,0 +1,134 @@
+package booth
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// ItemCard is one product in a Booth search result page
+type ItemCard struct {
+	ID       string `json:"id"`
+	Title    string `json:"title"`
+	Shop     string `json:"shop"`     // Display name of the shop
+	ShopID   string `json:"shopId"`   // Subdomain of the shop, e.g. "example" for example.booth.pm
+	URL      string `json:"url"`      // Canonical item URL
+	ImageURL string `json:"imageUrl"` // Thumbnail on booth.pximg.net
+}
+
+var (
+	cardStartPattern   = regexp.MustCompile(`<li[^>]+class="[^"]*\bitem-card\b`)
+	cardIDPattern      = regexp.MustCompile(`data-product-id="(\d+)"`)
+	cardNamePattern    = regexp.MustCompile(`data-product-name="([^"]*)"`)
+	cardTitlePattern   = regexp.MustCompile(`class="[^"]*item-card__title-anchor[^"]*"[^>]*>([^<]+)<`)
+	cardShopPattern    = regexp.MustCompile(`class="[^"]*item-card__shop-name[^"]*"[^>]*>([^<]+)<`)
+	shopIDPattern      = regexp.MustCompile(`https://([a-z0-9_-]+)\.booth\.pm`)
+	itemURLPattern     = regexp.MustCompile(`https://(?:[a-z0-9_-]+\.)?booth\.pm/(?:[a-z]{2}/)?items/(\d+)`)
+	thumbnailPattern   = regexp.MustCompile(`https://booth\.pximg\.net/[a-zA-Z0-9/._-]+\.(?:jpg|jpeg|png|webp)`)
+	imageResizePattern = regexp.MustCompile(`/c/\d+x\d+_[a-z0-9_]+/`)
+)
+
+// ItemID returns the item ID of a Booth product URL, or "" if it isn't one
+func ItemID(productURL string) string {
+	m := itemURLPattern.FindStringSubmatch(productURL)
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}
+
+// SearchURL returns the Booth search page URL for a query
+func SearchURL(query string) string {
+	return fmt.Sprintf("https://booth.pm/ja/search/%s", url.PathEscape(query))
+}
+
+// FetchSearchPage downloads the Booth search result page for a query
+func FetchSearchPage(query string) (string, error) {
+	body, err := fetchPage(SearchURL(query))
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch search page: %w", err)
+	}
+	return body, nil
+}
+
+// ParseSearchResults extracts the item cards from a Booth search page, in
+// page order. If the page layout isn't recognized, the bare item URLs found
+// in the page are returned instead.
+func ParseSearchResults(page string) []ItemCard {
+	starts := cardStartPattern.FindAllStringIndex(page, -1)
+
+	var cards []ItemCard
+	seen := make(map[string]bool)
+	for i, start := range starts {
+		end := len(page)
+		if i+1 < len(starts) {
+			end = starts[i+1][0]
+		}
+
+		card := parseCard(page[start[0]:end])
+		if card.ID == "" || seen[card.ID] {
+			continue
+		}
+		seen[card.ID] = true
+		cards = append(cards, card)
+	}
+
+	if len(cards) > 0 {
+		return cards
+	}
+
+	// Unknown layout: fall back to item links
+	for _, m := range itemURLPattern.FindAllStringSubmatch(page, -1) {
+		if seen[m[1]] {
+			continue
+		}
+		seen[m[1]] = true
+		card := ItemCard{ID: m[1], URL: m[0]}
+		if sm := shopIDPattern.FindStringSubmatch(m[0]); sm != nil {
+			card.ShopID = sm[1]
+		}
+		cards = append(cards, card)
+	}
+	return cards
+}
+
+func parseCard(chunk string) ItemCard {
+	var card ItemCard
+
+	if m := cardIDPattern.FindStringSubmatch(chunk); m != nil {
+		card.ID = m[1]
+	} else if m := itemURLPattern.FindStringSubmatch(chunk); m != nil {
+		card.ID = m[1]
+	}
+
+	if m := cardNamePattern.FindStringSubmatch(chunk); m != nil {
+		card.Title = cleanText(m[1])
+	}
+	if m := cardTitlePattern.FindStringSubmatch(chunk); card.Title == "" && m != nil {
+		card.Title = cleanText(m[1])
+	}
+	if m := cardShopPattern.FindStringSubmatch(chunk); m != nil {
+		card.Shop = cleanText(m[1])
+	}
+	if m := shopIDPattern.FindStringSubmatch(chunk); m != nil {
+		card.ShopID = m[1]
+	}
+
+	if m := itemURLPattern.FindString(chunk); m != "" {
+		card.URL = m
+	} else if card.ID != "" {
+		card.URL = "https://booth.pm/ja/items/" + card.ID
+	}
+
+	if m := thumbnailPattern.FindString(chunk); m != "" {
+		card.ImageURL = imageResizePattern.ReplaceAllString(m, "/")
+	}
+
+	return card
+}
+
+func cleanText(s string) string {
+	return strings.Join(strings.Fields(html.UnescapeString(s)), " ")
+}
@@ -0,0 +1,126 @@
+// Package catalog exports product metadata to JSON or CSV and merges such
+// exports back into the database, e.g. to move metadata to another machine.
+package catalog
+
+import (
+	"aslm/booth"
+	"aslm/db"
+	"aslm/organize"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Version is the catalog format version written by Write
+const Version = 3
+
+// Formats accepted by Write and Read
+const (
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+)
+
+// Catalog is an export of product metadata
+type Catalog struct {
+	Version    int             `json:"version"`
+	ExportedAt time.Time       `json:"exportedAt"`
+	PeerID     string          `json:"peerId,omitempty"`   // Install that wrote a synced catalog
+	PeerName   string          `json:"peerName,omitempty"` // e.g. the member's name or host name
+	Seen       map[string]Seen `json:"seen,omitempty"`     // Peer catalogs the writer has merged, by peer ID
+	Products   []Entry         `json:"products"`
+}
+
+// Entry is the metadata of one product
+type Entry struct {
+	Path     string   `json:"path,omitempty"` // Left out of synced catalogs
+	RootID   string   `json:"rootId,omitempty"`
+	RelPath  string   `json:"relPath,omitempty"` // "/"-separated, relative to the root
+	Name     string   `json:"name"`
+	Version  string   `json:"version,omitempty"` // From the folder name, e.g. "v1.2"
+	BoothID  string   `json:"boothId,omitempty"` // Item ID from the Booth URL
+	URL      string   `json:"url,omitempty"`
+	ImageURL string   `json:"imageUrl,omitempty"`
+	Shop     string   `json:"shop,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+
+	// Set in synced catalogs only
+	Files    []string             `json:"files,omitempty"`    // SHA-256 of the product's downloads (.zip, .unitypackage)
+	Modified map[string]time.Time `json:"modified,omitempty"` // Last change of each field, by db.Field* name
+}
+
+// Build returns a catalog of every registered product
+func Build() (*Catalog, error) {
+	products, err := db.ListProducts()
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Catalog{Version: Version, ExportedAt: time.Now().UTC(), Products: make([]Entry, 0, len(products))}
+	for _, p := range products {
+		c.Products = append(c.Products, entryFor(p))
+	}
+	return c, nil
+}
+
+func entryFor(p db.ProductInfo) Entry {
+	return Entry{
+		Path:     p.Path,
+		RootID:   p.RootID,
+		RelPath:  p.RelPath,
+		Name:     p.Name,
+		Version:  organize.ExtractVersion(p.Name),
+		BoothID:  booth.ItemID(p.Url),
+		URL:      p.Url,
+		ImageURL: p.ImageUrl,
+		Shop:     p.ShopName,
+		Tags:     p.Tags,
+	}
+}
+
+// FormatOf returns the format for a file name: CSV for ".csv", else JSON
+func FormatOf(name string) string {
+	if strings.EqualFold(filepath.Ext(name), ".csv") {
+		return FormatCSV
+	}
+	return FormatJSON
+}
+
+// Write writes c as indented JSON or as CSV
+func Write(w io.Writer, c *Catalog, format string) error {
+	switch format {
+	case FormatJSON, "":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(c)
+	case FormatCSV:
+		return writeCSV(w, c)
+	}
+	return fmt.Errorf("unknown catalog format: %s", format)
+}
+
+// Read parses a catalog written by Write
+func Read(r io.Reader, format string) (*Catalog, error) {
+	switch format {
+	case FormatJSON, "":
+		var c Catalog
+		if err := json.NewDecoder(r).Decode(&c); err != nil {
+			return nil, fmt.Errorf("invalid catalog: %w", err)
+		}
+		if c.Version > Version {
+			return nil, fmt.Errorf("catalog version %d is newer than supported (%d)", c.Version, Version)
+		}
+		for i := range c.Products {
+			// Version 1 catalogs have no Booth IDs
+			if c.Products[i].BoothID == "" {
+				c.Products[i].BoothID = booth.ItemID(c.Products[i].URL)
+			}
+		}
+		return &c, nil
+	case FormatCSV:
+		return readCSV(r)
+	}
+	return nil, fmt.Errorf("unknown catalog format: %s", format)
+}
@@ -0,0 +1,96 @@
+package catalog
+
+import (
+	"aslm/booth"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// csvColumns is the CSV header; columns are matched by name when reading
+var csvColumns = []string{"root_id", "rel_path", "path", "name", "version", "booth_id", "url", "image_url", "shop", "tags"}
+
+// tagSeparator joins tags in the tags column
+const tagSeparator = ";"
+
+func writeCSV(w io.Writer, c *Catalog) error {
+	// A BOM makes Excel read the file as UTF-8 instead of Shift_JIS
+	if _, err := io.WriteString(w, "\ufeff"); err != nil {
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvColumns); err != nil {
+		return err
+	}
+	for _, e := range c.Products {
+		record := []string{e.RootID, e.RelPath, e.Path, e.Name, e.Version, e.BoothID, e.URL, e.ImageURL, e.Shop, strings.Join(e.Tags, tagSeparator)}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func readCSV(r io.Reader) (*Catalog, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("invalid catalog: %w", err)
+	}
+	index := make(map[string]int)
+	for i, name := range header {
+		index[strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))] = i
+	}
+	if _, ok := index["path"]; !ok {
+		if _, ok := index["rel_path"]; !ok {
+			return nil, fmt.Errorf("invalid catalog: no path or rel_path column")
+		}
+	}
+
+	c := &Catalog{Version: Version, ExportedAt: time.Now().UTC()}
+	for line := 2; ; line++ {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("invalid catalog: %w", err)
+		}
+
+		field := func(name string) string {
+			if i, ok := index[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		e := Entry{
+			RootID:   field("root_id"),
+			RelPath:  field("rel_path"),
+			Path:     field("path"),
+			Name:     field("name"),
+			Version:  field("version"),
+			BoothID:  field("booth_id"),
+			URL:      field("url"),
+			ImageURL: field("image_url"),
+			Shop:     field("shop"),
+		}
+		for _, tag := range strings.Split(field("tags"), tagSeparator) {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				e.Tags = append(e.Tags, tag)
+			}
+		}
+		if e.BoothID == "" {
+			e.BoothID = booth.ItemID(e.URL)
+		}
+		if e.Path == "" && e.RelPath == "" && e.BoothID == "" {
+			return nil, fmt.Errorf("invalid catalog: line %d has no path, rel_path or booth_id", line)
+		}
+		c.Products = append(c.Products, e)
+	}
+	return c, nil
+}
@@ -0,0 +1,223 @@
+package catalog
+
+import (
+	"aslm/db"
+	"fmt"
+	"slices"
+)
+
+// Conflict strategies for Import, deciding what happens when a field is set
+// both locally and in the catalog
+const (
+	KeepLocal = "keep"      // Local values win; only empty fields are filled, and tags only if there are none
+	Overwrite = "overwrite" // Catalog values win; empty catalog values never clear local ones
+	MergeTags = "merge"     // Like KeepLocal, but tags from both sides are combined
+)
+
+// Ways an entry can be matched to a local product, in the order they are tried
+const (
+	MatchBooth   = "booth"   // Same Booth item ID
+	MatchRelPath = "relPath" // Same root and relative path, or a relative path unique across roots
+	MatchPath    = "path"    // Same absolute path
+	MatchFile    = "file"    // Same download hash (synced catalogs only)
+)
+
+// ImportOptions controls Import
+type ImportOptions struct {
+	Strategy string `json:"strategy"` // KeepLocal if empty
+	DryRun   bool   `json:"dryRun"`   // Only report what would change
+}
+
+// ImportChange is an update Import made (or would make) to a local product
+type ImportChange struct {
+	Path      string   `json:"path"`      // Local product
+	MatchedBy string   `json:"matchedBy"` // MatchBooth, MatchRelPath or MatchPath
+	Fields    []string `json:"fields"`    // e.g. "url", "tags"
+}
+
+// ImportResult summarizes an Import
+type ImportResult struct {
+	Changes   []ImportChange  `json:"changes"`
+	Unchanged int             `json:"unchanged"`           // Matched products that needed no change
+	Missing   []string        `json:"missing,omitempty"`   // Entries that match no local product
+	Ambiguous []string        `json:"ambiguous,omitempty"` // Entries matching several products, or a product already matched
+	Failed    []db.BulkResult `json:"failed,omitempty"`
+}
+
+// Import merges c into the database. Each entry is matched to a local
+// product by Booth item ID, then relative path, then absolute path, and its
+// fields are merged according to opts.Strategy. All changes are journaled as
+// one batch, so a single Undo reverts the import.
+func Import(c *Catalog, opts ImportOptions) (*ImportResult, error) {
+	strategy := opts.Strategy
+	if strategy == "" {
+		strategy = KeepLocal
+	}
+	if strategy != KeepLocal && strategy != Overwrite && strategy != MergeTags {
+		return nil, fmt.Errorf("unknown import strategy: %s", strategy)
+	}
+
+	products, err := db.ListProducts()
+	if err != nil {
+		return nil, err
+	}
+	idx := newIndex(products)
+
+	result := &ImportResult{Changes: []ImportChange{}}
+	matched := make(map[string]bool)
+	var patches []db.PathPatch
+	for _, e := range c.Products {
+		local, by, ambiguous := idx.match(e)
+		name := describe(e)
+		switch {
+		case ambiguous:
+			result.Ambiguous = append(result.Ambiguous, name)
+			continue
+		case local == nil:
+			result.Missing = append(result.Missing, name)
+			continue
+		case matched[local.Path]:
+			result.Ambiguous = append(result.Ambiguous, name)
+			continue
+		}
+		matched[local.Path] = true
+
+		patch, fields := merge(*local, e, strategy)
+		if len(fields) == 0 {
+			result.Unchanged++
+			continue
+		}
+		result.Changes = append(result.Changes, ImportChange{Path: local.Path, MatchedBy: by, Fields: fields})
+		patches = append(patches, db.PathPatch{Path: local.Path, Patch: patch})
+	}
+
+	if opts.DryRun || len(patches) == 0 {
+		return result, nil
+	}
+	results, err := db.PatchProducts(patches)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range results {
+		if r.Error != "" {
+			result.Failed = append(result.Failed, r)
+		}
+	}
+	return result, nil
+}
+
+// merge returns the patch that applies e to local with the given strategy,
+// and the names of the fields it changes
+func merge(local db.ProductInfo, e Entry, strategy string) (db.ProductPatch, []string) {
+	var patch db.ProductPatch
+	var fields []string
+
+	values := []struct {
+		name   string
+		local  string
+		remote string
+		target **string
+	}{
+		{"url", local.Url, e.URL, &patch.Url},
+		{"imageUrl", local.ImageUrl, e.ImageURL, &patch.ImageUrl},
+		{"shop", local.ShopName, e.Shop, &patch.ShopName},
+	}
+	for _, v := range values {
+		if v.remote == "" || v.remote == v.local {
+			continue
+		}
+		if v.local == "" || strategy == Overwrite {
+			remote := v.remote
+			*v.target = &remote
+			fields = append(fields, v.name)
+		}
+	}
+
+	var added []string
+	for _, tag := range e.Tags {
+		if !slices.Contains(local.Tags, tag) {
+			added = append(added, tag)
+		}
+	}
+	switch {
+	case len(e.Tags) == 0:
+	case strategy == Overwrite:
+		if len(added) > 0 || len(local.Tags) != len(e.Tags) {
+			tags := slices.Clone(e.Tags)
+			patch.Tags = &tags
+			fields = append(fields, "tags")
+		}
+	case strategy == MergeTags || len(local.Tags) == 0:
+		if len(added) > 0 {
+			patch.AddTags = added
+			fields = append(fields, "tags")
+		}
+	}
+
+	return patch, fields
+}
+
+// index looks up local products the ways entries are matched
+type index struct {
+	byBooth   map[string][]*db.ProductInfo
+	byRootRel map[string]*db.ProductInfo
+	byRel     map[string][]*db.ProductInfo
+	byPath    map[string]*db.ProductInfo
+}
+
+func newIndex(products []db.ProductInfo) *index {
+	idx := &index{
+		byBooth:   make(map[string][]*db.ProductInfo),
+		byRootRel: make(map[string]*db.ProductInfo),
+		byRel:     make(map[string][]*db.ProductInfo),
+		byPath:    make(map[string]*db.ProductInfo),
+	}
+	for i := range products {
+		p := &products[i]
+		if id := entryFor(*p).BoothID; id != "" {
+			idx.byBooth[id] = append(idx.byBooth[id], p)
+		}
+		if p.RootID != "" {
+			idx.byRootRel[p.RootID+"\x00"+p.RelPath] = p
+			idx.byRel[p.RelPath] = append(idx.byRel[p.RelPath], p)
+		}
+		idx.byPath[p.Path] = p
+	}
+	return idx
+}
+
+// match finds the local product for e. A Booth ID shared by several local
+// products (e.g. two versions of an item) falls back to the path, and is
+// ambiguous if that doesn't decide it either.
+func (idx *index) match(e Entry) (*db.ProductInfo, string, bool) {
+	candidates := idx.byBooth[e.BoothID]
+	if e.BoothID != "" && len(candidates) == 1 {
+		return candidates[0], MatchBooth, false
+	}
+
+	if p := idx.byRootRel[e.RootID+"\x00"+e.RelPath]; e.RelPath != "" && p != nil {
+		return p, MatchRelPath, false
+	}
+	if rel := idx.byRel[e.RelPath]; e.RelPath != "" && len(rel) == 1 {
+		return rel[0], MatchRelPath, false
+	}
+	if p := idx.byPath[e.Path]; e.Path != "" && p != nil {
+		return p, MatchPath, false
+	}
+
+	ambiguous := len(candidates) > 1 || len(idx.byRel[e.RelPath]) > 1
+	return nil, "", ambiguous
+}
+
+// describe names an entry in the result
+func describe(e Entry) string {
+	switch {
+	case e.Path != "":
+		return e.Path
+	case e.RelPath != "":
+		return e.RelPath
+	case e.BoothID != "":
+		return "booth:" + e.BoothID
+	}
+	return e.Name
+}
@@ -0,0 +1,527 @@
+package catalog
+
+import (
+	"aslm/config"
+	"aslm/db"
+	"aslm/dedupe"
+	"aslm/inbox"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultSyncInterval is how often Syncer merges the sync folder
+const DefaultSyncInterval = 5 * time.Minute
+
+// Peer identifies an install sharing the sync folder. Each install writes
+// its own catalog, <ID>.json, so installs never write the same file.
+type Peer struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// SyncResult summarizes a Sync
+type SyncResult struct {
+	Peers     []string          `json:"peers"`            // Names of the peers whose catalogs were merged
+	Unchanged int               `json:"unchanged"`        // Peer catalogs not changed since the last sync
+	Changes   []ImportChange    `json:"changes"`          // Local products updated from peers
+	Conflicts []db.SyncConflict `json:"conflicts"`        // Also appended to the conflict log
+	Failed    []db.BulkResult   `json:"failed,omitempty"` // Updates that could not be saved
+	Errors    []string          `json:"errors,omitempty"` // Peer catalogs that could not be read
+	Exported  int               `json:"exported"`         // Products in this install's catalog
+}
+
+// SelfPeer returns this install's identity from the config, creating and
+// saving its ID on first use. The name defaults to the host name.
+func SelfPeer(cfg *config.Config) (Peer, error) {
+	if cfg.SyncID == "" {
+		cfg.SyncID = config.NewRootID()
+		if err := config.SaveConfig(cfg); err != nil {
+			return Peer{}, fmt.Errorf("failed to save sync ID: %w", err)
+		}
+	}
+	name := strings.TrimSpace(cfg.SyncName)
+	if name == "" {
+		name, _ = os.Hostname()
+	}
+	return Peer{ID: cfg.SyncID, Name: name}, nil
+}
+
+// syncMu serializes Sync, so SyncNow and the background Syncer never merge
+// and publish at the same time
+var syncMu sync.Mutex
+
+// Sync merges the catalogs other installs wrote to folder into the
+// database, then writes this install's catalog there.
+//
+// Products are matched by Booth item ID and by the hashes of their
+// downloads, since paths differ between installs. Each field is merged on its
+// own and the most recent change wins. When both sides changed a field since
+// the previous sync with that peer, the losing value is kept in the conflict
+// log. All updates are journaled as one batch, so a single Undo reverts them.
+func Sync(folder string, self Peer) (*SyncResult, error) {
+	syncMu.Lock()
+	defer syncMu.Unlock()
+
+	if info, err := os.Stat(folder); err != nil {
+		return nil, fmt.Errorf("sync folder not available: %w", err)
+	} else if !info.IsDir() {
+		return nil, fmt.Errorf("sync folder is not a folder: %s", folder)
+	}
+
+	local, err := loadLocal()
+	if err != nil {
+		return nil, err
+	}
+	idx := newSyncIndex(local)
+
+	result := &SyncResult{Peers: []string{}, Changes: []ImportChange{}, Conflicts: []db.SyncConflict{}}
+	names, err := filepath.Glob(filepath.Join(folder, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var peers []db.SyncPeer
+	for _, name := range names {
+		c, err := readSynced(name)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", filepath.Base(name), err))
+			continue
+		}
+		if c.PeerID == "" {
+			c.PeerID = strings.TrimSuffix(filepath.Base(name), ".json")
+		}
+		if c.PeerID == self.ID {
+			continue
+		}
+		if c.PeerName == "" {
+			c.PeerName = c.PeerID
+		}
+
+		peer, err := db.GetSyncPeer(c.PeerID)
+		if err != nil {
+			return nil, err
+		}
+		if peer == nil {
+			peer = &db.SyncPeer{ID: c.PeerID}
+		} else if peer.ExportedAt.Equal(c.ExportedAt) {
+			result.Unchanged++
+			continue
+		}
+
+		for _, e := range c.Products {
+			if p := idx.match(e); p != nil {
+				result.Conflicts = append(result.Conflicts, p.merge(e, c.PeerName, c.Seen[self.ID], Seen{peer.ExportedAt, peer.SyncedAt})...)
+			}
+		}
+		result.Peers = append(result.Peers, c.PeerName)
+		peer.Name = c.PeerName
+		peer.ExportedAt = c.ExportedAt
+		peers = append(peers, *peer)
+	}
+
+	var patches []db.PathPatch
+	for _, p := range local {
+		if len(p.changed) == 0 {
+			continue
+		}
+		patches = append(patches, db.PathPatch{Path: p.info.Path, Patch: p.patch()})
+		result.Changes = append(result.Changes, ImportChange{Path: p.info.Path, MatchedBy: p.matchedBy, Fields: p.changedFields()})
+	}
+	if len(patches) > 0 {
+		results, err := db.PatchProducts(patches)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range results {
+			if r.Error != "" {
+				result.Failed = append(result.Failed, r)
+			}
+		}
+	}
+	if len(result.Conflicts) > 0 {
+		if err := db.RecordSyncConflicts(result.Conflicts); err != nil {
+			return nil, err
+		}
+	}
+	now := time.Now()
+	for _, peer := range peers {
+		peer.SyncedAt = now
+		if err := db.SaveSyncPeer(peer); err != nil {
+			return nil, err
+		}
+	}
+
+	// Publish the merged state; product files were hashed above
+	files := make(map[string][]string, len(local))
+	for _, p := range local {
+		files[p.info.Path] = p.files
+	}
+	result.Exported, err = export(folder, self, files)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Seen records a merge of another install's catalog
+type Seen struct {
+	ExportedAt time.Time `json:"exportedAt"` // ExportedAt of the merged catalog
+	MergedAt   time.Time `json:"mergedAt"`
+}
+
+// includes reports whether a value changed at modified was merged before a
+// change made at change, i.e. whether that change knowingly replaced it
+func (s Seen) includes(modified time.Time, change time.Time) bool {
+	return !s.MergedAt.IsZero() && !s.ExportedAt.Before(modified) && !s.MergedAt.After(change)
+}
+
+// Syncer runs Sync on an interval
+type Syncer struct {
+	Folder   string
+	Self     Peer
+	Interval time.Duration
+	OnSync   func(result *SyncResult) // Called after each sync that changed something
+}
+
+// Run syncs until ctx is cancelled
+func (s *Syncer) Run(ctx context.Context) {
+	interval := s.Interval
+	if interval <= 0 {
+		interval = DefaultSyncInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		result, err := Sync(s.Folder, s.Self)
+		if err != nil {
+			log.Printf("Sync failed: %v", err)
+		} else if s.OnSync != nil && (len(result.Changes) > 0 || len(result.Conflicts) > 0) {
+			s.OnSync(result)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// syncProduct is a local product while peer catalogs are merged into it
+type syncProduct struct {
+	info      db.ProductInfo
+	boothID   string
+	files     []string
+	values    map[string]string // By db.Field*; tags are sorted and joined by tagJoin
+	times     map[string]time.Time
+	changed   map[string]bool
+	matchedBy string
+}
+
+// tagJoin joins sorted tags into a single comparable value
+const tagJoin = "\n"
+
+var syncFields = []string{db.FieldURL, db.FieldImageURL, db.FieldShopName, db.FieldTags}
+
+func loadLocal() ([]*syncProduct, error) {
+	products, err := db.ListProducts()
+	if err != nil {
+		return nil, err
+	}
+	times, err := db.FieldTimes()
+	if err != nil {
+		return nil, err
+	}
+
+	local := make([]*syncProduct, 0, len(products))
+	for _, info := range products {
+		p := &syncProduct{
+			info:    info,
+			boothID: entryFor(info).BoothID,
+			values:  entryValues(entryFor(info)),
+			times:   times[info.Path],
+			changed: make(map[string]bool),
+		}
+		if p.times == nil {
+			p.times = make(map[string]time.Time)
+		}
+		if !info.Offline {
+			p.files = downloadHashes(info.Path)
+		}
+		local = append(local, p)
+	}
+	return local, nil
+}
+
+func entryValues(e Entry) map[string]string {
+	tags := slices.Clone(e.Tags)
+	sort.Strings(tags)
+	return map[string]string{
+		db.FieldURL:      e.URL,
+		db.FieldImageURL: e.ImageURL,
+		db.FieldShopName: e.Shop,
+		db.FieldTags:     strings.Join(tags, tagJoin),
+	}
+}
+
+// merge applies the fields of e that win over the local values and returns
+// the conflicts: values that lost although the winning side hadn't seen them
+// when it made its change. remoteSeen is the peer's last merge of this
+// install's catalog, localSeen this install's previous merge of the peer's.
+func (p *syncProduct) merge(e Entry, peer string, remoteSeen Seen, localSeen Seen) []db.SyncConflict {
+	concurrent := func(value string, t time.Time, seen Seen, change time.Time) bool {
+		return (value != "" || !t.IsZero()) && !seen.includes(t, change)
+	}
+
+	var conflicts []db.SyncConflict
+	remote := entryValues(e)
+	for _, field := range syncFields {
+		lv, rv := p.values[field], remote[field]
+		lt, rt := p.times[field], e.Modified[field]
+		if lv == rv {
+			continue
+		}
+
+		conflict := db.SyncConflict{
+			Path:        p.info.Path,
+			Field:       field,
+			LocalValue:  displayValue(field, lv),
+			RemoteValue: displayValue(field, rv),
+			Peer:        peer,
+		}
+		switch {
+		case rt.After(lt):
+			if concurrent(lv, lt, remoteSeen, rt) {
+				conflict.Kept = db.KeptRemote
+				conflicts = append(conflicts, conflict)
+			}
+			p.set(field, rv, rt)
+		case lv == "" && lt.IsZero() && rt.IsZero():
+			// Neither side has a time (set before sync was used); fill the gap
+			p.set(field, rv, rt)
+		default:
+			if concurrent(rv, rt, localSeen, lt) {
+				conflict.Kept = db.KeptLocal
+				conflicts = append(conflicts, conflict)
+			}
+		}
+	}
+	return conflicts
+}
+
+func (p *syncProduct) set(field string, value string, modified time.Time) {
+	p.values[field] = value
+	p.times[field] = modified
+	p.changed[field] = true
+}
+
+// patch returns the changed fields with their times
+func (p *syncProduct) patch() db.ProductPatch {
+	patch := db.ProductPatch{Times: make(map[string]time.Time)}
+	for field := range p.changed {
+		value := p.values[field]
+		switch field {
+		case db.FieldURL:
+			patch.Url = &value
+		case db.FieldImageURL:
+			patch.ImageUrl = &value
+		case db.FieldShopName:
+			patch.ShopName = &value
+		case db.FieldTags:
+			tags := []string{}
+			if value != "" {
+				tags = strings.Split(value, tagJoin)
+			}
+			patch.Tags = &tags
+		}
+		patch.Times[field] = p.times[field]
+	}
+	return patch
+}
+
+func (p *syncProduct) changedFields() []string {
+	var fields []string
+	for _, field := range syncFields {
+		if p.changed[field] {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+func displayValue(field string, value string) string {
+	if field == db.FieldTags {
+		return strings.ReplaceAll(value, tagJoin, ", ")
+	}
+	return value
+}
+
+// syncIndex finds local products by Booth ID and download hash
+type syncIndex struct {
+	byBooth map[string][]*syncProduct
+	byFile  map[string][]*syncProduct
+}
+
+func newSyncIndex(local []*syncProduct) *syncIndex {
+	idx := &syncIndex{byBooth: make(map[string][]*syncProduct), byFile: make(map[string][]*syncProduct)}
+	for _, p := range local {
+		if p.boothID != "" {
+			idx.byBooth[p.boothID] = append(idx.byBooth[p.boothID], p)
+		}
+		for _, hash := range p.files {
+			idx.byFile[hash] = append(idx.byFile[hash], p)
+		}
+	}
+	return idx
+}
+
+// match returns the local product e describes, or nil if there is none or
+// it is ambiguous. A Booth ID shared by several local products (e.g. two
+// versions of an item) is narrowed down by the download hashes.
+func (idx *syncIndex) match(e Entry) *syncProduct {
+	var byFile []*syncProduct
+	for _, hash := range e.Files {
+		for _, p := range idx.byFile[hash] {
+			if !slices.Contains(byFile, p) {
+				byFile = append(byFile, p)
+			}
+		}
+	}
+
+	if candidates := idx.byBooth[e.BoothID]; e.BoothID != "" && len(candidates) > 0 {
+		if len(candidates) == 1 {
+			candidates[0].matchedBy = MatchBooth
+			return candidates[0]
+		}
+		var both []*syncProduct
+		for _, p := range candidates {
+			if slices.Contains(byFile, p) {
+				both = append(both, p)
+			}
+		}
+		if len(both) == 1 {
+			both[0].matchedBy = MatchBooth
+			return both[0]
+		}
+		return nil
+	}
+
+	if len(byFile) == 1 {
+		byFile[0].matchedBy = MatchFile
+		return byFile[0]
+	}
+	return nil
+}
+
+// downloadHashes returns the sorted SHA-256 of the downloads in a product
+// folder. Hashes are cached in the database, so only new or changed files
+// are read.
+func downloadHashes(root string) []string {
+	var hashes []string
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !slices.Contains(inbox.Extensions, strings.ToLower(filepath.Ext(path))) {
+			return nil
+		}
+		hash, err := dedupe.FileHash(path)
+		if err != nil {
+			log.Printf("Failed to hash %s: %v", path, err)
+			return nil
+		}
+		if !slices.Contains(hashes, hash) {
+			hashes = append(hashes, hash)
+		}
+		return nil
+	})
+	sort.Strings(hashes)
+	return hashes
+}
+
+// export writes this install's catalog to folder, leaving out paths. Products
+// without a Booth ID or downloads can't be matched elsewhere and are skipped.
+// The file is only rewritten when a product changed, so peers don't merge
+// the same catalog again; Seen only matters for changed products.
+func export(folder string, self Peer, files map[string][]string) (int, error) {
+	products, err := db.ListProducts()
+	if err != nil {
+		return 0, err
+	}
+	times, err := db.FieldTimes()
+	if err != nil {
+		return 0, err
+	}
+
+	peers, err := db.ListSyncPeers()
+	if err != nil {
+		return 0, err
+	}
+
+	c := &Catalog{Version: Version, ExportedAt: time.Now().UTC(), PeerID: self.ID, PeerName: self.Name, Seen: make(map[string]Seen), Products: []Entry{}}
+	for _, p := range peers {
+		c.Seen[p.ID] = Seen{ExportedAt: p.ExportedAt.UTC(), MergedAt: p.SyncedAt.UTC()}
+	}
+	for _, p := range products {
+		e := entryFor(p)
+		e.Path, e.RootID, e.RelPath = "", "", ""
+		e.Files = files[p.Path]
+		e.Modified = times[p.Path]
+		if e.BoothID == "" && len(e.Files) == 0 {
+			continue
+		}
+		c.Products = append(c.Products, e)
+	}
+
+	path := filepath.Join(folder, self.ID+".json")
+	if old, err := readSynced(path); err == nil && old.PeerName == c.PeerName && sameEntries(old.Products, c.Products) {
+		return len(c.Products), nil
+	}
+
+	tmp, err := os.CreateTemp(folder, "."+self.ID+"-*.tmp")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+	if err := Write(tmp, c, FormatJSON); err != nil {
+		tmp.Close()
+		return 0, err
+	}
+	if err := tmp.Close(); err != nil {
+		return 0, err
+	}
+	// CreateTemp makes the file private; the other members must read it
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return 0, err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return 0, err
+	}
+	return len(c.Products), nil
+}
+
+func readSynced(path string) (*Catalog, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f, FormatJSON)
+}
+
+func sameEntries(a []Entry, b []Entry) bool {
+	ja, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	jb, err := json.Marshal(b)
+	return err == nil && string(ja) == string(jb)
+}
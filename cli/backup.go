@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"aslm/backup"
+	"aslm/db"
+	"fmt"
+	"text/tabwriter"
+	"time"
+)
+
+func runBackup(args []string) error {
+	if len(args) == 0 {
+		args = []string{"create"}
+	}
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	_, keep := backup.Settings(cfg)
+
+	switch action := args[0]; action {
+	case "create":
+		if len(args) != 1 {
+			return errUsage
+		}
+		info, err := backup.Create(keep)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(Stdout, "backed up to %s (%d bytes)\n", info.Path, info.Size)
+		return nil
+	case "list":
+		if len(args) != 1 {
+			return errUsage
+		}
+		backups, err := backup.List()
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(Stdout, 0, 4, 2, ' ', 0)
+		for _, b := range backups {
+			fmt.Fprintf(w, "%s\t%s\t%d\n", b.Name, b.CreatedAt.Format(time.DateTime), b.Size)
+		}
+		return w.Flush()
+	case "restore":
+		if len(args) != 2 {
+			return errUsage
+		}
+		// The app would keep using the replaced database file
+		if pid := db.RunningInstance(); pid != 0 {
+			return fmt.Errorf("aslm is running (process %d); close it first or restore from its settings", pid)
+		}
+		if err := backup.Restore(args[1]); err != nil {
+			return err
+		}
+		fmt.Fprintf(Stdout, "restored %s\n", args[1])
+		return nil
+	default:
+		return fmt.Errorf("%w: unknown action %q", errUsage, action)
+	}
+}
@@ -0,0 +1,167 @@
+package cli
+
+import (
+	"aslm/catalog"
+	"fmt"
+	"io"
+	"os"
+)
+
+func runExport(args []string) error {
+	fs := newFlags("export")
+	output := fs.String("o", "", "output file (default: standard output)")
+	format := fs.String("format", "", "json or csv (default: from the file extension, else json)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return errUsage
+	}
+	if *format == "" {
+		*format = catalog.FormatOf(*output)
+	}
+
+	c, err := catalog.Build()
+	if err != nil {
+		return err
+	}
+	if *output == "" {
+		return catalog.Write(Stdout, c, *format)
+	}
+
+	f, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if err := catalog.Write(f, c, *format); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Fprintf(Stderr, "exported %d products to %s\n", len(c.Products), *output)
+	return nil
+}
+
+func runImport(args []string) error {
+	fs := newFlags("import")
+	strategy := fs.String("strategy", catalog.KeepLocal, "keep, overwrite or merge")
+	format := fs.String("format", "", "json or csv (default: from the file extension, else json)")
+	dryRun := fs.Bool("dry-run", false, "only show what would change")
+	asJSON := fs.Bool("json", false, "print JSON")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errUsage
+	}
+	name := fs.Arg(0)
+	if *format == "" {
+		*format = catalog.FormatOf(name)
+	}
+
+	var r io.Reader = os.Stdin
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	c, err := catalog.Read(r, *format)
+	if err != nil {
+		return err
+	}
+	result, err := catalog.Import(c, catalog.ImportOptions{Strategy: *strategy, DryRun: *dryRun})
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		if err := printJSON(result); err != nil {
+			return err
+		}
+	} else {
+		for _, change := range result.Changes {
+			fmt.Fprintf(Stdout, "%s (by %s): %v\n", change.Path, change.MatchedBy, change.Fields)
+		}
+		for _, entry := range result.Missing {
+			fmt.Fprintf(Stderr, "no match: %s\n", entry)
+		}
+		for _, entry := range result.Ambiguous {
+			fmt.Fprintf(Stderr, "ambiguous: %s\n", entry)
+		}
+		for _, f := range result.Failed {
+			fmt.Fprintf(Stderr, "%s: %s\n", f.Path, f.Error)
+		}
+		verb := "updated"
+		if *dryRun {
+			verb = "would be updated"
+		}
+		fmt.Fprintf(Stdout, "%d %s, %d unchanged, %d without match, %d ambiguous, %d failed\n",
+			len(result.Changes)-len(result.Failed), verb, result.Unchanged, len(result.Missing), len(result.Ambiguous), len(result.Failed))
+	}
+
+	if len(result.Failed) > 0 {
+		return fmt.Errorf("%d products failed", len(result.Failed))
+	}
+	return nil
+}
+
+func runSync(args []string) error {
+	fs := newFlags("sync")
+	folder := fs.String("folder", "", "shared folder (default: syncFolder from the config)")
+	asJSON := fs.Bool("json", false, "print JSON")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return errUsage
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	if *folder == "" {
+		*folder = cfg.SyncFolder
+	}
+	if *folder == "" {
+		return fmt.Errorf("%w: no sync folder configured", errUsage)
+	}
+	self, err := catalog.SelfPeer(cfg)
+	if err != nil {
+		return err
+	}
+
+	result, err := catalog.Sync(*folder, self)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return printJSON(result)
+	}
+
+	for _, change := range result.Changes {
+		fmt.Fprintf(Stdout, "%s (by %s): %v\n", change.Path, change.MatchedBy, change.Fields)
+	}
+	for _, c := range result.Conflicts {
+		fmt.Fprintf(Stdout, "conflict: %s %s: kept %s (local %q, %s %q)\n", c.Path, c.Field, c.Kept, c.LocalValue, c.Peer, c.RemoteValue)
+	}
+	for _, e := range result.Errors {
+		fmt.Fprintf(Stderr, "unreadable: %s\n", e)
+	}
+	for _, f := range result.Failed {
+		fmt.Fprintf(Stderr, "%s: %s\n", f.Path, f.Error)
+	}
+	fmt.Fprintf(Stdout, "%d peers merged (%d unchanged), %d updated, %d conflicts, %d products published\n",
+		len(result.Peers), result.Unchanged, len(result.Changes)-len(result.Failed), len(result.Conflicts), result.Exported)
+
+	if len(result.Failed) > 0 {
+		return fmt.Errorf("%d products failed", len(result.Failed))
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+// Package cli runs aslm's subcommands without starting the window, e.g.
+// "aslm scan" from cron. It uses the same packages as the App bindings.
+package cli
+
+import (
+	"aslm/config"
+	"aslm/db"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Output streams, replaceable for embedding
+var (
+	Stdout io.Writer = os.Stdout
+	Stderr io.Writer = os.Stderr
+)
+
+// errUsage is returned by a command whose arguments are wrong
+var errUsage = errors.New("invalid arguments")
+
+type command struct {
+	usage   string // Arguments after the command name
+	summary string
+	run     func(args []string) error
+}
+
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"scan":   {"[--root <id>] [--json]", "register product folders in the library roots", runScan},
+		"search": {"[--tag <tag>]... [--shop <shop>] [--untagged] [--json] [text]", "find products", runSearch},
+		"tag":    {"add|remove <tag> <path>...", "add or remove a tag on products", runTag},
+		"link":   {"[--no-fetch] <path> <url>", "set a product's URL, fetching its image and shop from Booth", runLink},
+		"export": {"[-o <file>] [--format json|csv]", "write the metadata of all products as JSON or CSV", runExport},
+		"import": {"[--strategy keep|overwrite|merge] [--format json|csv] [--dry-run] [--json] <file>|-", "merge an exported catalog into the library", runImport},
+		"backup": {"[create|list|restore <name>]", "back up the database, list backups or restore one", runBackup},
+		"sync":   {"[--folder <dir>] [--json]", "merge metadata with other installs through a shared folder", runSync},
+		"doctor": {"[--json]", "check the config, database and library roots", runDoctor},
+		"serve":  {"[--port <port>] [--show-token]", "serve the local REST API without the window", runServe},
+		"help":   {"", "show this help", runHelp},
+	}
+}
+
+// IsCommand reports whether name is a subcommand, i.e. whether the app should
+// run headless instead of opening the window
+func IsCommand(name string) bool {
+	_, ok := commands[name]
+	return ok
+}
+
+// Run runs the subcommand in args[0] and returns the process exit code:
+// 0 on success, 1 on failure and 2 for wrong arguments
+func Run(args []string) int {
+	if len(args) == 0 || !IsCommand(args[0]) {
+		runHelp(nil)
+		return 2
+	}
+	name, cmd := args[0], commands[args[0]]
+
+	if name != "help" {
+		if err := db.InitDB(); err != nil {
+			fmt.Fprintf(Stderr, "aslm: failed to open database: %v\n", err)
+			return 1
+		}
+	}
+
+	err := cmd.run(args[1:])
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, errUsage) || errors.Is(err, flag.ErrHelp):
+		if err != errUsage && !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintf(Stderr, "aslm %s: %v\n", name, err)
+		}
+		fmt.Fprintf(Stderr, "usage: aslm %s %s\n", name, cmd.usage)
+		return 2
+	default:
+		fmt.Fprintf(Stderr, "aslm %s: %v\n", name, err)
+		return 1
+	}
+}
+
+func runHelp(args []string) error {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(Stderr, "usage: aslm [--data-dir <dir>] [--portable] <command> [arguments]")
+	fmt.Fprintln(Stderr, "\nWithout a command the window is opened. Commands:")
+	for _, name := range names {
+		fmt.Fprintf(Stderr, "  %-7s %s\n", name, commands[name].summary)
+		if usage := commands[name].usage; usage != "" {
+			fmt.Fprintf(Stderr, "          aslm %s %s\n", name, usage)
+		}
+	}
+	fmt.Fprintf(Stderr, "\nData directory: %s\n", config.DataDir())
+	return nil
+}
+
+// newFlags returns a flag set that reports errors through Run
+func newFlags(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	return fs
+}
+
+// parseFlags parses args and turns flag errors into usage errors
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+	return nil
+}
+
+// stringList is a flag that may be repeated
+type stringList []string
+
+func (l *stringList) String() string     { return strings.Join(*l, ",") }
+func (l *stringList) Set(v string) error { *l = append(*l, v); return nil }
+
+func printJSON(v any) error {
+	enc := json.NewEncoder(Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func loadConfig() (*config.Config, error) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	return cfg, nil
+}
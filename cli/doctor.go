@@ -0,0 +1,207 @@
+package cli
+
+import (
+	"aslm/ai"
+	"aslm/backup"
+	"aslm/config"
+	"aslm/db"
+	"aslm/library"
+	"aslm/secret"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Check statuses reported by doctor
+const (
+	checkOK   = "ok"
+	checkWarn = "warn"
+	checkFail = "fail"
+)
+
+// check is one doctor finding
+type check struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+// maxListed is how many missing product folders doctor lists by name
+const maxListed = 10
+
+func runDoctor(args []string) error {
+	fs := newFlags("doctor")
+	asJSON := fs.Bool("json", false, "print JSON")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return errUsage
+	}
+
+	var checks []check
+	add := func(name string, status string, format string, args ...any) {
+		checks = append(checks, check{Name: name, Status: status, Message: fmt.Sprintf(format, args...)})
+	}
+
+	add("data", checkOK, "%s", config.DataDir())
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		add("config", checkFail, "%v", err)
+		return report(checks, *asJSON)
+	}
+	problems := cfg.Validate()
+	for _, p := range problems {
+		status := checkWarn
+		if p.Severity == config.SeverityError {
+			status = checkFail
+		}
+		add("config", status, "%s", p.Error())
+	}
+	if len(problems) == 0 {
+		add("config", checkOK, "valid")
+	}
+	if keys := cfg.Overrides(); len(keys) > 0 {
+		add("config", checkOK, "overridden from the environment: %v", keys)
+	}
+
+	if integrity, err := db.CheckIntegrity(true); err != nil {
+		add("database", checkFail, "%v", err)
+	} else if !integrity.OK {
+		for _, p := range integrity.Problems {
+			add("database", checkFail, "%s", p)
+		}
+		for _, p := range integrity.ForeignKeys {
+			add("database", checkFail, "%s", p)
+		}
+	} else {
+		add("database", checkOK, "integrity check passed")
+	}
+	checkBackups(add)
+
+	states, warnings, err := library.Sync(cfg)
+	if err != nil {
+		add("roots", checkFail, "%v", err)
+	}
+	for _, w := range warnings {
+		add("roots", checkWarn, "%s", w)
+	}
+	for i, state := range states {
+		r := cfg.Roots[i]
+		status := checkOK
+		if state == library.StateOffline {
+			status = checkWarn
+		}
+		add("roots", status, "%s (%s): %s", r.Label, r.Path, state)
+	}
+
+	checkProducts(add)
+	checkSecrets(add)
+
+	if _, err := ai.FromConfig(cfg); err != nil {
+		add("ai", checkWarn, "%v", err)
+	} else {
+		add("ai", checkOK, "%s configured", providerName(cfg))
+	}
+
+	return report(checks, *asJSON)
+}
+
+// checkProducts reports products whose folder is gone from an online root.
+// Products in offline roots are expected to be missing.
+func checkProducts(add func(string, string, string, ...any)) {
+	products, err := db.ListProducts()
+	if err != nil {
+		add("products", checkFail, "%v", err)
+		return
+	}
+
+	var missing []string
+	outside := 0
+	for _, p := range products {
+		if p.RootID == "" {
+			outside++
+		}
+		if p.Offline {
+			continue
+		}
+		if _, err := os.Stat(p.Path); os.IsNotExist(err) {
+			missing = append(missing, p.Path)
+		}
+	}
+
+	add("products", checkOK, "%d registered", len(products))
+	if outside > 0 {
+		add("products", checkWarn, "%d outside every library root", outside)
+	}
+	for i, path := range missing {
+		if i == maxListed {
+			add("products", checkWarn, "... and %d more missing", len(missing)-maxListed)
+			break
+		}
+		add("products", checkWarn, "folder missing: %s", path)
+	}
+}
+
+// backupAge is how old the newest backup may be before doctor warns
+const backupAge = 7 * 24 * time.Hour
+
+// checkSecrets warns about secrets kept in the encrypted file fallback,
+// whose key is stored beside it in the data folder
+func checkSecrets(add func(string, string, string, ...any)) {
+	names, err := secret.FileSecrets()
+	if err != nil {
+		add("secrets", checkWarn, "%v", err)
+	} else if len(names) > 0 {
+		add("secrets", checkWarn, "%s kept in %s next to their encryption key; anyone with a copy of the data folder can read them", strings.Join(names, ", "), config.DataDir())
+	}
+}
+
+// checkBackups reports the newest database backup
+func checkBackups(add func(string, string, string, ...any)) {
+	backups, err := backup.List()
+	switch {
+	case err != nil:
+		add("backup", checkWarn, "%v", err)
+	case len(backups) == 0:
+		add("backup", checkWarn, "no backups in %s", backup.Dir())
+	case time.Since(backups[0].CreatedAt) > backupAge:
+		add("backup", checkWarn, "newest backup is from %s", backups[0].CreatedAt.Format(time.DateTime))
+	default:
+		add("backup", checkOK, "%d backups, newest %s", len(backups), backups[0].CreatedAt.Format(time.DateTime))
+	}
+}
+
+func providerName(cfg *config.Config) string {
+	if cfg.AIProvider == "" {
+		return ai.ProviderGemini
+	}
+	return cfg.AIProvider
+}
+
+// report prints the checks and fails if any check failed
+func report(checks []check, asJSON bool) error {
+	failed := 0
+	for _, c := range checks {
+		if c.Status == checkFail {
+			failed++
+		}
+	}
+
+	if asJSON {
+		if err := printJSON(checks); err != nil {
+			return err
+		}
+	} else {
+		for _, c := range checks {
+			fmt.Fprintf(Stdout, "[%-4s] %-8s %s\n", c.Status, c.Name, c.Message)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d checks failed", failed)
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package cli
+
+import (
+	"aslm/booth"
+	"aslm/db"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+)
+
+func runSearch(args []string) error {
+	fs := newFlags("search")
+	var tags stringList
+	fs.Var(&tags, "tag", "required tag (repeatable)")
+	shop := fs.String("shop", "", "shop name")
+	untagged := fs.Bool("untagged", false, "only products without tags")
+	asJSON := fs.Bool("json", false, "print JSON")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	products, err := db.FindProducts(db.ProductQuery{
+		Text:     strings.Join(fs.Args(), " "),
+		Tags:     tags,
+		Shop:     *shop,
+		Untagged: *untagged,
+	})
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		return printJSON(products)
+	}
+	w := tabwriter.NewWriter(Stdout, 0, 4, 2, ' ', 0)
+	for _, p := range products {
+		status := ""
+		if p.Offline {
+			status = " (offline)"
+		}
+		fmt.Fprintf(w, "%s%s\t%s\t%s\n", p.Path, status, p.ShopName, strings.Join(p.Tags, ", "))
+	}
+	return w.Flush()
+}
+
+func runTag(args []string) error {
+	if len(args) < 3 {
+		return errUsage
+	}
+	action, tag := args[0], args[1]
+	paths := make([]string, len(args)-2)
+	for i, arg := range args[2:] {
+		path, err := productPath(arg)
+		if err != nil {
+			return err
+		}
+		paths[i] = path
+	}
+
+	var patch db.ProductPatch
+	switch action {
+	case "add":
+		patch.AddTags = []string{tag}
+	case "remove":
+		patch.RemoveTags = []string{tag}
+	default:
+		return fmt.Errorf("%w: unknown action %q", errUsage, action)
+	}
+
+	results, err := db.BulkEdit(paths, patch)
+	if err != nil {
+		return err
+	}
+	failed := 0
+	for _, r := range results {
+		if r.Error != "" {
+			fmt.Fprintf(Stderr, "%s: %s\n", r.Path, r.Error)
+			failed++
+		}
+	}
+	fmt.Fprintf(Stdout, "%d of %d products updated\n", len(results)-failed, len(results))
+	if failed > 0 {
+		return fmt.Errorf("%d products failed", failed)
+	}
+	return nil
+}
+
+func runLink(args []string) error {
+	fs := newFlags("link")
+	noFetch := fs.Bool("no-fetch", false, "don't fetch the image and shop from the page")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return errUsage
+	}
+	path, err := productPath(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	url := strings.TrimSpace(fs.Arg(1))
+
+	patch := db.ProductPatch{Url: &url}
+	if !*noFetch && strings.Contains(url, "booth.pm/") {
+		info, err := booth.ExtractBoothProductInfo(url)
+		if err != nil {
+			return fmt.Errorf("failed to fetch %s (use --no-fetch to only set the URL): %w", url, err)
+		}
+		patch.ImageUrl = &info.ImageURL
+		if info.ShopName != "" {
+			patch.ShopName = &info.ShopName
+		}
+	}
+
+	if err := db.PatchProduct(path, patch); err != nil {
+		return err
+	}
+	fmt.Fprintf(Stdout, "%s -> %s\n", path, url)
+	return nil
+}
+
+// productPath turns a path argument into the absolute, clean form products
+// are registered with, so "." or "./Hat/" work from the shell
+func productPath(arg string) (string, error) {
+	path, err := filepath.Abs(arg)
+	if err != nil {
+		return "", fmt.Errorf("invalid path %q: %w", arg, err)
+	}
+	return path, nil
+}
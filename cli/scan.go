@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"aslm/config"
+	"aslm/library"
+	"fmt"
+)
+
+func runScan(args []string) error {
+	fs := newFlags("scan")
+	rootID := fs.String("root", "", "only scan the root with this ID")
+	asJSON := fs.Bool("json", false, "print JSON")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return errUsage
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	if err := syncRoots(cfg); err != nil {
+		return err
+	}
+
+	var roots []config.Root
+	if *rootID != "" {
+		root := cfg.FindRoot(*rootID)
+		if root == nil {
+			return fmt.Errorf("unknown library root: %s", *rootID)
+		}
+		roots = append(roots, *root)
+	} else {
+		roots = library.Online(cfg.Roots)
+	}
+
+	var results []library.ScanResult
+	for _, root := range roots {
+		if state := library.State(root); state != library.StateOnline {
+			fmt.Fprintf(Stderr, "skipping %s: %s\n", root.Label, state)
+			continue
+		}
+		result, err := library.Scan(root)
+		if err != nil {
+			return fmt.Errorf("failed to scan %s: %w", root.Path, err)
+		}
+		results = append(results, *result)
+	}
+	// Newly registered products need their root and relative path
+	if err := syncRoots(cfg); err != nil {
+		return err
+	}
+
+	if *asJSON {
+		return printJSON(results)
+	}
+	for _, r := range results {
+		fmt.Fprintf(Stdout, "%s: %d new, %d existing\n", r.Root, r.Registered, r.Existing)
+	}
+	return nil
+}
+
+// syncRoots updates the library roots in the database, reporting warnings on
+// stderr so they don't mix with --json output
+func syncRoots(cfg *config.Config) error {
+	_, warnings, err := library.Sync(cfg)
+	for _, w := range warnings {
+		fmt.Fprintf(Stderr, "warning: %s\n", w)
+	}
+	return err
+}
@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"aslm/server"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+func runServe(args []string) error {
+	fs := newFlags("serve")
+	port := fs.Int("port", 0, "port on 127.0.0.1 (default: configured port)")
+	showToken := fs.Bool("show-token", false, "print the API token")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return errUsage
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	token, err := server.LoadToken()
+	if err != nil {
+		return fmt.Errorf("failed to load API token: %w", err)
+	}
+
+	srv := &server.Server{Port: cfg.APIServerPort, Token: token}
+	if *port != 0 {
+		srv.Port = *port
+	}
+	if err := srv.Start(); err != nil {
+		return err
+	}
+	fmt.Fprintf(Stderr, "listening on http://%s/api/v1 (press Ctrl+C to stop)\n", srv.Addr())
+	if *showToken {
+		fmt.Fprintf(Stdout, "%s\n", token)
+	}
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	<-stop
+	return srv.Stop()
+}
@@ -1,64 +1,253 @@
 package config
 
 import (
+	"crypto/rand"
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
 )
 
 // Config represents application configuration
 type Config struct {
-	HomePath     string `json:"homePath"`
-	GeminiAPIKey string `json:"geminiApiKey"`
+	Version          int    `json:"version"`                // Schema version; older files are upgraded on load
+	Roots            []Root `json:"roots"`                  // Library folders; the first enabled one receives new downloads
+	HomePath         string `json:"homePath,omitempty"`     // Legacy single library folder; migrated into Roots on load
+	GeminiAPIKey     string `json:"geminiApiKey,omitempty"` // Legacy plaintext key; moved to the secret store on startup
+	InboxPath        string `json:"inboxPath"`              // Folder watched for new downloads; empty disables the inbox
+	InboxTemplate    string `json:"inboxTemplate"`          // Where filed downloads go under the home root, e.g. "{shop}/{title}"
+	OrganizeTemplate string `json:"organizeTemplate"`       // Folder layout used by reorganize, e.g. "{tag:category}/{shop}/{title} ({version})"
+	AIProvider       string `json:"aiProvider"`             // "gemini" (default) or "openai" for an OpenAI-compatible server
+	AIModel          string `json:"aiModel"`                // Model name; empty uses the provider's default
+	AIEndpoint       string `json:"aiEndpoint"`             // Base URL of the OpenAI-compatible server, e.g. "http://localhost:8080/v1"
+	AIEmbeddingModel string `json:"aiEmbeddingModel"`       // Embedding model for semantic search; empty uses the provider's default
+	AIAPIKey         string `json:"aiApiKey,omitempty"`     // Legacy plaintext key; moved to the secret store on startup
+	AIMaxInputTokens int    `json:"aiMaxInputTokens"`       // Estimated prompt size cap for product lookups; 0 uses the default
+	AIDailyTokens    int    `json:"aiDailyTokens"`          // Daily token budget (input + output); 0 is unlimited
+	AIDailyRequests  int    `json:"aiDailyRequests"`        // Daily request budget; 0 is unlimited
+	APIServerEnabled bool   `json:"apiServerEnabled"`       // Serve the local REST API while the app runs
+	APIServerPort    int    `json:"apiServerPort"`          // Port on 127.0.0.1; 0 uses the default
+	AutoBackup       bool   `json:"autoBackup"`             // Back up the database on a schedule while the app runs
+	BackupInterval   int    `json:"backupIntervalHours"`    // Hours between automatic backups; 0 uses the default
+	BackupKeep       int    `json:"backupKeep"`             // Backups kept by rotation; 0 uses the default
+	SyncFolder       string `json:"syncFolder"`             // Shared folder for syncing metadata with other installs; empty disables sync
+	SyncName         string `json:"syncName"`               // Shown to other installs in conflicts; empty uses the host name
+	SyncID           string `json:"syncId,omitempty"`       // Identifies this install in the sync folder; created on first sync
+
+	unknownKeys []string            // Keys in the file that aren't config fields, reported by Validate
+	overrides   map[string]override // Keys overridden from the environment, by JSON key
+	envErrors   map[string]string   // Environment overrides that couldn't be parsed, by JSON key
+}
+
+// CurrentVersion is the schema version written by SaveConfig
+//
+//	1: single HomePath (files without a version)
+//	2: list of library roots
+const CurrentVersion = 2
+
+// Root is a library folder, e.g. one per drive
+type Root struct {
+	ID      string    `json:"id"` // Stable ID stored with each product
+	Label   string    `json:"label"`
+	Path    string    `json:"path"`
+	Enabled bool      `json:"enabled"`
+	Scan    ScanRules `json:"scan"`
 }
 
+// ScanRules decide which folders in a root are registered as products
+type ScanRules struct {
+	Include  []string `json:"include,omitempty"`  // Name patterns (filepath.Match); if set, only matching folders are products
+	Exclude  []string `json:"exclude,omitempty"`  // Name patterns skipped anywhere in the path, e.g. "_old", ".*"
+	MaxDepth int      `json:"maxDepth,omitempty"` // Deepest folder level registered (1 = direct children); 0 is unlimited
+}
+
+// homeRootID is the ID of the root created from the legacy HomePath
+const homeRootID = "home"
+
+// DefaultInboxTemplate is used when InboxTemplate is empty
+const DefaultInboxTemplate = "{shop}/{title}"
+
+// DefaultOrganizeTemplate is used when OrganizeTemplate is empty
+const DefaultOrganizeTemplate = "{shop}/{title}"
+
 var configPath string
 
 func init() {
-	// Get user config directory
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		// Fallback to current directory
-		configPath = "config.json"
-		return
-	}
+	// The directory is created when something is first saved, so that
+	// --data-dir doesn't leave an empty default directory behind
+	configPath = filepath.Join(defaultDataDir(), "config.json")
+}
 
-	configDir := filepath.Join(homeDir, ".aslm")
-	os.MkdirAll(configDir, 0755)
-	configPath = filepath.Join(configDir, "config.json")
+// DataDir returns the directory holding aslm's config and data files
+func DataDir() string {
+	return filepath.Dir(configPath)
 }
 
-// LoadConfig loads configuration from file
-func LoadConfig() (*Config, error) {
-	// Default config
-	defaultConfig := &Config{
-		HomePath:     "D:/VRChatAssetPack",
-		GeminiAPIKey: "",
+// SetDataDir moves config and data files to dir, e.g. for --data-dir. It
+// must be called before the database is opened.
+func SetDataDir(dir string) {
+	os.MkdirAll(dir, 0755)
+	configPath = filepath.Join(dir, "config.json")
+}
+
+// Default returns the configuration used for missing keys
+func Default() *Config {
+	return &Config{
+		Version:          CurrentVersion,
+		Roots:            []Root{{ID: homeRootID, Label: "VRChatAssetPack", Path: "D:/VRChatAssetPack", Enabled: true}},
+		InboxTemplate:    DefaultInboxTemplate,
+		OrganizeTemplate: DefaultOrganizeTemplate,
+		AutoBackup:       true,
 	}
+}
 
+// LoadConfig loads configuration from file. Keys missing from the file keep
+// their default values, and files written by older versions are upgraded
+// (and saved) to CurrentVersion.
+func LoadConfig() (*Config, error) {
 	data, err := os.ReadFile(configPath)
 	if err != nil {
 		// If file doesn't exist, return default config
 		if os.IsNotExist(err) {
-			return defaultConfig, nil
+			cfg := Default()
+			cfg.applyEnv()
+			return cfg, nil
 		}
 		return nil, err
 	}
 
-	var cfg Config
-	if err := json.Unmarshal(data, &cfg); err != nil {
-		return nil, err
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", configPath, err)
+	}
+
+	cfg := Default()
+	cfg.Version = 1 // Files without a version predate versioning
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", configPath, err)
+	}
+	cfg.unknownKeys = unknownKeys(keys)
+	cfg.applyEnv()
+
+	if cfg.Version > CurrentVersion {
+		return nil, fmt.Errorf("config file %s is version %d, newer than this version of the app (%d)", configPath, cfg.Version, CurrentVersion)
+	}
+	if cfg.Version < CurrentVersion {
+		upgrade(cfg, keys)
+		if err := SaveConfig(cfg); err != nil {
+			return nil, err
+		}
 	}
 
-	return &cfg, nil
+	return cfg, nil
 }
 
-// SaveConfig saves configuration to file
+// upgrade migrates cfg from its version to CurrentVersion. keys holds the
+// raw keys of the file, to tell missing keys from defaults.
+func upgrade(cfg *Config, keys map[string]json.RawMessage) {
+	if cfg.Version < 2 {
+		// Migrate the single HomePath into a root
+		if _, ok := keys["roots"]; !ok && cfg.HomePath != "" {
+			cfg.Roots = []Root{{ID: homeRootID, Label: filepath.Base(cfg.HomePath), Path: cfg.HomePath, Enabled: true}}
+		}
+		cfg.HomePath = ""
+	}
+	cfg.Version = CurrentVersion
+}
+
+// unknownKeys returns the keys that don't match a Config field, sorted
+func unknownKeys(keys map[string]json.RawMessage) []string {
+	known := make(map[string]bool)
+	t := reflect.TypeOf(Config{})
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		known[name] = true
+	}
+
+	var unknown []string
+	for key := range keys {
+		if !known[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+// HomeRoot returns the path of the first enabled root, where new downloads
+// and extracted archives go. It is "" if no root is enabled.
+func (c *Config) HomeRoot() string {
+	for _, r := range c.Roots {
+		if r.Enabled {
+			return r.Path
+		}
+	}
+	return ""
+}
+
+// InboxFolderTemplate returns the template downloads are filed with
+func (c *Config) InboxFolderTemplate() string {
+	if c.InboxTemplate == "" {
+		return DefaultInboxTemplate
+	}
+	return c.InboxTemplate
+}
+
+// FindRoot returns the root with the given ID
+func (c *Config) FindRoot(id string) *Root {
+	for i := range c.Roots {
+		if c.Roots[i].ID == id {
+			return &c.Roots[i]
+		}
+	}
+	return nil
+}
+
+// NewRootID returns a random ID for a new root
+func NewRootID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	b[6] = b[6]&0x0f | 0x40 // UUID version 4
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// SaveConfig saves configuration to file, readable only by the user. The
+// file is written to a temporary file and renamed over the old one, so a
+// crash never leaves a half-written config.
 func SaveConfig(cfg *Config) error {
-	data, err := json.MarshalIndent(cfg, "", "  ")
+	cfg.Version = CurrentVersion
+	data, err := json.MarshalIndent(cfg.withoutEnv(), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(DataDir(), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(DataDir(), "config-*.tmp")
 	if err != nil {
 		return err
 	}
+	defer os.Remove(tmp.Name()) // No-op once renamed
 
-	return os.WriteFile(configPath, data, 0644)
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	// CreateTemp already uses 0600, but be explicit about it
+	if err := os.Chmod(tmp.Name(), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), configPath)
 }
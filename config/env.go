@@ -0,0 +1,198 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Environment variables and files that choose the data directory
+const (
+	// HomeEnv overrides the data directory, like --data-dir
+	HomeEnv = "ASLM_HOME"
+	// PortableEnv set to 1 keeps data next to the executable, like a PortableFile
+	PortableEnv = "ASLM_PORTABLE"
+	// PortableFile next to the executable turns on portable mode
+	PortableFile = "portable"
+	// portableDataDir is the data directory next to the executable in portable mode
+	portableDataDir = "aslm-data"
+)
+
+// EnvPrefix prefixes per-key overrides, e.g. ASLM_AI_PROVIDER for aiProvider
+const EnvPrefix = "ASLM_"
+
+// envExcluded are keys that can't be overridden: API keys belong in the
+// secret store, and the others aren't settings
+var envExcluded = map[string]bool{
+	"version":      true,
+	"homePath":     true,
+	"geminiApiKey": true,
+	"aiApiKey":     true,
+	"syncId":       true,
+}
+
+// defaultDataDir picks the data directory: ASLM_HOME, then portable mode,
+// then ~/.aslm
+func defaultDataDir() string {
+	if dir := os.Getenv(HomeEnv); dir != "" {
+		return dir
+	}
+	if dir := portableDir(); dir != "" {
+		return dir
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		// Fallback to current directory
+		return "."
+	}
+	return filepath.Join(homeDir, ".aslm")
+}
+
+// portableDir returns the data directory next to the executable if portable
+// mode is on, or ""
+func portableDir() string {
+	exe, err := os.Executable()
+	if err != nil {
+		return ""
+	}
+	exeDir := filepath.Dir(exe)
+	if os.Getenv(PortableEnv) != "1" {
+		if _, err := os.Stat(filepath.Join(exeDir, PortableFile)); err != nil {
+			return ""
+		}
+	}
+	return filepath.Join(exeDir, portableDataDir)
+}
+
+// ApplyArgs handles the data directory flags and returns the remaining
+// arguments:
+//
+//	--data-dir <dir>, --data-dir=<dir>  use dir for config and data
+//	--portable                          keep data next to the executable
+func ApplyArgs(args []string) []string {
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--data-dir" && i+1 < len(args):
+			SetDataDir(args[i+1])
+			i++
+		case strings.HasPrefix(arg, "--data-dir="):
+			SetDataDir(strings.TrimPrefix(arg, "--data-dir="))
+		case arg == "--portable":
+			if exe, err := os.Executable(); err == nil {
+				SetDataDir(filepath.Join(filepath.Dir(exe), portableDataDir))
+			}
+		default:
+			rest = append(rest, arg)
+		}
+	}
+	return rest
+}
+
+// EnvName returns the environment variable overriding a JSON key, e.g.
+// "aiDailyTokens" -> "ASLM_AI_DAILY_TOKENS"
+func EnvName(key string) string {
+	var b strings.Builder
+	b.WriteString(EnvPrefix)
+	for i, r := range key {
+		if unicode.IsUpper(r) && i > 0 {
+			b.WriteByte('_')
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+	return b.String()
+}
+
+// applyEnv overrides string, int and bool settings from ASLM_* variables.
+// The file values are kept so SaveConfig doesn't persist the overrides.
+func (c *Config) applyEnv() {
+	v := reflect.ValueOf(c).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		key, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if key == "" || key == "-" || envExcluded[key] {
+			continue
+		}
+		value, ok := os.LookupEnv(EnvName(key))
+		if !ok {
+			continue
+		}
+
+		field := v.Field(i)
+		original := field.Interface()
+		switch field.Kind() {
+		case reflect.String:
+			field.SetString(value)
+		case reflect.Int:
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				c.envError(key, "not a number: "+value)
+				continue
+			}
+			field.SetInt(int64(n))
+		case reflect.Bool:
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				c.envError(key, "not true or false: "+value)
+				continue
+			}
+			field.SetBool(b)
+		default:
+			continue
+		}
+
+		if c.overrides == nil {
+			c.overrides = make(map[string]override)
+		}
+		c.overrides[key] = override{file: original, env: field.Interface()}
+	}
+}
+
+// override is a setting replaced by an environment variable
+type override struct {
+	file any // Value from the config file
+	env  any // Value from the environment
+}
+
+func (c *Config) envError(key string, message string) {
+	if c.envErrors == nil {
+		c.envErrors = make(map[string]string)
+	}
+	c.envErrors[key] = message
+}
+
+// Overrides returns the keys currently overridden from the environment
+func (c *Config) Overrides() []string {
+	var keys []string
+	for key := range c.overrides {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// withoutEnv returns a copy of c with overridden keys set back to their file
+// values, unless they were changed after loading
+func (c *Config) withoutEnv() *Config {
+	if len(c.overrides) == 0 {
+		return c
+	}
+
+	copied := *c
+	v := reflect.ValueOf(&copied).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		key, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		o, ok := c.overrides[key]
+		if ok && v.Field(i).Interface() == o.env {
+			v.Field(i).Set(reflect.ValueOf(o.file))
+		}
+	}
+	return &copied
+}
@@ -0,0 +1,164 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Severities of a FieldError
+const (
+	SeverityError   = "error"   // The value can't be used
+	SeverityWarning = "warning" // The value works but is probably a mistake, e.g. a folder on an unplugged drive
+)
+
+// FieldError is a problem with one config value
+type FieldError struct {
+	Field    string `json:"field"` // JSON path, e.g. "roots[1].path"
+	Message  string `json:"message"`
+	Severity string `json:"severity"`
+}
+
+func (e FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// Validate checks every field and returns the problems found, including keys
+// in the file that aren't config fields
+func (c *Config) Validate() []FieldError {
+	var problems []FieldError
+	add := func(field string, severity string, format string, args ...any) {
+		problems = append(problems, FieldError{Field: field, Message: fmt.Sprintf(format, args...), Severity: severity})
+	}
+
+	for _, key := range c.unknownKeys {
+		add(key, SeverityWarning, "unknown setting (ignored)")
+	}
+	envKeys := make([]string, 0, len(c.envErrors))
+	for key := range c.envErrors {
+		envKeys = append(envKeys, key)
+	}
+	sort.Strings(envKeys)
+	for _, key := range envKeys {
+		add(key, SeverityWarning, "%s ignored: %s", EnvName(key), c.envErrors[key])
+	}
+
+	if c.HomeRoot() == "" {
+		add("roots", SeverityError, "no library root is enabled")
+	}
+	ids := make(map[string]bool)
+	for i, r := range c.Roots {
+		field := fmt.Sprintf("roots[%d]", i)
+		switch {
+		case r.ID == "":
+			add(field+".id", SeverityError, "missing ID")
+		case ids[r.ID]:
+			add(field+".id", SeverityError, "duplicate ID %s", r.ID)
+		}
+		ids[r.ID] = true
+
+		if strings.TrimSpace(r.Path) == "" {
+			add(field+".path", SeverityError, "path is empty")
+		} else if r.Enabled {
+			checkDir(add, field+".path", r.Path)
+		}
+		for j, pattern := range r.Scan.Include {
+			checkPattern(add, fmt.Sprintf("%s.scan.include[%d]", field, j), pattern)
+		}
+		for j, pattern := range r.Scan.Exclude {
+			checkPattern(add, fmt.Sprintf("%s.scan.exclude[%d]", field, j), pattern)
+		}
+		if r.Scan.MaxDepth < 0 {
+			add(field+".scan.maxDepth", SeverityError, "must not be negative")
+		}
+	}
+
+	if c.InboxPath != "" {
+		checkDir(add, "inboxPath", c.InboxPath)
+	}
+	if c.SyncFolder != "" {
+		checkDir(add, "syncFolder", c.SyncFolder)
+	}
+
+	switch c.AIProvider {
+	case "", "gemini", "openai":
+	default:
+		add("aiProvider", SeverityError, "unknown provider %q (expected \"gemini\" or \"openai\")", c.AIProvider)
+	}
+	if c.AIEndpoint != "" {
+		u, err := url.Parse(c.AIEndpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			add("aiEndpoint", SeverityError, "not an http(s) URL: %q", c.AIEndpoint)
+		}
+	}
+
+	counts := []struct {
+		field string
+		value int
+	}{
+		{"aiMaxInputTokens", c.AIMaxInputTokens},
+		{"aiDailyTokens", c.AIDailyTokens},
+		{"aiDailyRequests", c.AIDailyRequests},
+		{"backupIntervalHours", c.BackupInterval},
+		{"backupKeep", c.BackupKeep},
+	}
+	for _, n := range counts {
+		if n.value < 0 {
+			add(n.field, SeverityError, "must not be negative")
+		}
+	}
+	if c.APIServerPort < 0 || c.APIServerPort > 65535 {
+		add("apiServerPort", SeverityError, "not a port number: %d", c.APIServerPort)
+	}
+
+	return problems
+}
+
+// ValidationError returns the problems with SeverityError in the given fields
+// (and their subfields) as one error, or nil if there are none. With no
+// fields, all problems are considered.
+func ValidationError(problems []FieldError, fields ...string) error {
+	var errs []error
+	for _, p := range problems {
+		if p.Severity == SeverityError && inFields(p.Field, fields) {
+			errs = append(errs, p)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func inFields(field string, fields []string) bool {
+	if len(fields) == 0 {
+		return true
+	}
+	for _, f := range fields {
+		if field == f || strings.HasPrefix(field, f+".") || strings.HasPrefix(field, f+"[") {
+			return true
+		}
+	}
+	return false
+}
+
+// checkDir warns about folders that don't exist; they may be on a drive
+// that isn't connected
+func checkDir(add func(string, string, string, ...any), field string, path string) {
+	info, err := os.Stat(path)
+	switch {
+	case os.IsNotExist(err):
+		add(field, SeverityWarning, "folder does not exist: %s", path)
+	case err != nil:
+		add(field, SeverityWarning, "folder is not accessible: %v", err)
+	case !info.IsDir():
+		add(field, SeverityError, "not a folder: %s", path)
+	}
+}
+
+func checkPattern(add func(string, string, string, ...any), field string, pattern string) {
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		add(field, SeverityError, "invalid pattern %q", pattern)
+	}
+}
@@ -0,0 +1,61 @@
+package db
+
+// BulkResult is the outcome of a bulk edit for one product
+type BulkResult struct {
+	Path  string `json:"path"`
+	Error string `json:"error,omitempty"`
+}
+
+// PathPatch is a patch for the product at Path
+type PathPatch struct {
+	Path  string
+	Patch ProductPatch
+}
+
+// BulkEdit applies the same patch to every path in a single transaction.
+// A product that fails (e.g. it isn't registered) is rolled back on its own
+// and reported in its result; the others are still saved. All changes are
+// journaled as one batch, so a single Undo reverts the whole bulk edit.
+func BulkEdit(paths []string, patch ProductPatch) ([]BulkResult, error) {
+	patches := make([]PathPatch, len(paths))
+	for i, path := range paths {
+		patches[i] = PathPatch{Path: path, Patch: patch}
+	}
+	return PatchProducts(patches)
+}
+
+// PatchProducts is like BulkEdit with a different patch for each product
+func PatchProducts(patches []PathPatch) ([]BulkResult, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	batchID := NewBatchID()
+	results := make([]BulkResult, 0, len(patches))
+	for _, p := range patches {
+		path, patch := p.Path, p.Patch
+		result := BulkResult{Path: path}
+
+		if _, err := tx.Exec(`SAVEPOINT bulk_item`); err != nil {
+			return nil, err
+		}
+		if err := patchProductTx(tx, path, patch, batchID); err != nil {
+			result.Error = err.Error()
+			if _, err := tx.Exec(`ROLLBACK TO bulk_item`); err != nil {
+				return nil, err
+			}
+		}
+		if _, err := tx.Exec(`RELEASE bulk_item`); err != nil {
+			return nil, err
+		}
+
+		results = append(results, result)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, wrapError(err)
+	}
+	return results, nil
+}
@@ -1,38 +1,56 @@
 package db
 
 import (
+	"aslm/config"
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
+	"unicode/utf8"
 
 	_ "github.com/glebarez/go-sqlite"
 )
 
 var DB *sql.DB
 
+// querier is satisfied by both *sql.DB and *sql.Tx
+type querier interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
 func InitDB() error {
-	// ユーザーのホームディレクトリを取得して、そこにDBファイルを保存する
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
+	// 設定と同じデータディレクトリ (~/.aslm、ASLM_HOME または --data-dir) にDBファイルを保存する
+	appDir := config.DataDir()
+	if err := os.MkdirAll(appDir, 0755); err != nil {
 		return err
 	}
 
-	appDir := filepath.Join(homeDir, ".aslm")
-	if err := os.MkdirAll(appDir, 0755); err != nil {
+	if err := open(Path()); err != nil {
 		return err
 	}
+	return createTables()
+}
 
-	dbPath := filepath.Join(appDir, "aslm.db")
+// Path returns the location of the database file
+func Path() string {
+	return filepath.Join(config.DataDir(), "aslm.db")
+}
 
-	var openErr error
-	DB, openErr = sql.Open("sqlite", dbPath)
-	if openErr != nil {
-		return openErr
+// open opens the database at path. Foreign keys are enforced and WAL mode
+// lets readers (e.g. a backup) run alongside a writer. Pragmas are set in the
+// DSN because database/sql may open several connections.
+func open(path string) error {
+	var err error
+	DB, err = sql.Open("sqlite", path+"?_pragma=foreign_keys(1)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return err
 	}
-
-	return createTables()
+	return DB.Ping()
 }
 
 func createTables() error {
@@ -48,7 +66,7 @@ func createTables() error {
 
 	CREATE TABLE IF NOT EXISTS tags (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		name TEXT UNIQUE NOT NULL
+		name TEXT NOT NULL
 	);
 
 	CREATE TABLE IF NOT EXISTS product_tags (
@@ -58,6 +76,127 @@ func createTables() error {
 		FOREIGN KEY (product_id) REFERENCES products(id),
 		FOREIGN KEY (tag_id) REFERENCES tags(id)
 	);
+
+	CREATE TABLE IF NOT EXISTS file_hashes (
+		path TEXT PRIMARY KEY,
+		size INTEGER NOT NULL,
+		mtime INTEGER NOT NULL,
+		partial_hash TEXT,
+		full_hash TEXT
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_file_hashes_full ON file_hashes(full_hash);
+
+	CREATE TABLE IF NOT EXISTS duplicate_ignores (
+		hash TEXT PRIMARY KEY
+	);
+
+	CREATE TABLE IF NOT EXISTS inbox_items (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		path TEXT NOT NULL,
+		title TEXT,
+		shop_name TEXT,
+		url TEXT,
+		image_url TEXT,
+		destination TEXT,
+		status TEXT NOT NULL,
+		reason TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_inbox_items_path ON inbox_items(path);
+
+	CREATE TABLE IF NOT EXISTS inbox_group_folders (
+		path TEXT PRIMARY KEY
+	);
+
+	CREATE TABLE IF NOT EXISTS operations (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		batch_id TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		product_id INTEGER,
+		path TEXT NOT NULL,
+		before TEXT,
+		after TEXT,
+		undone INTEGER NOT NULL DEFAULT 0,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_operations_product ON operations(product_id);
+	CREATE INDEX IF NOT EXISTS idx_operations_batch ON operations(batch_id);
+
+	CREATE TABLE IF NOT EXISTS tag_aliases (
+		alias TEXT PRIMARY KEY COLLATE NOCASE,
+		tag_id INTEGER NOT NULL,
+		FOREIGN KEY (tag_id) REFERENCES tags(id)
+	);
+
+	CREATE TABLE IF NOT EXISTS ai_usage (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		task TEXT NOT NULL,
+		model TEXT NOT NULL,
+		input_tokens INTEGER NOT NULL DEFAULT 0,
+		output_tokens INTEGER NOT NULL DEFAULT 0,
+		latency_ms INTEGER NOT NULL DEFAULT 0,
+		success INTEGER NOT NULL,
+		error TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_ai_usage_created ON ai_usage(created_at);
+
+	CREATE TABLE IF NOT EXISTS product_embeddings (
+		product_id INTEGER NOT NULL,
+		model TEXT NOT NULL,
+		text_hash TEXT NOT NULL,
+		vector BLOB NOT NULL,
+		PRIMARY KEY (product_id, model),
+		FOREIGN KEY (product_id) REFERENCES products(id)
+	);
+
+	CREATE VIRTUAL TABLE IF NOT EXISTS products_fts USING fts5(
+		path UNINDEXED, name, shop, tags, description, tokenize = 'trigram'
+	);
+
+	CREATE TABLE IF NOT EXISTS saved_queries (
+		name TEXT PRIMARY KEY,
+		query TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS library_roots (
+		id TEXT PRIMARY KEY,
+		path TEXT NOT NULL,
+		online INTEGER NOT NULL DEFAULT 1,
+		checked_at DATETIME
+	);
+
+	CREATE TABLE IF NOT EXISTS product_field_times (
+		product_id INTEGER NOT NULL,
+		field TEXT NOT NULL,
+		modified_at INTEGER NOT NULL,
+		PRIMARY KEY (product_id, field),
+		FOREIGN KEY (product_id) REFERENCES products(id)
+	);
+
+	CREATE TABLE IF NOT EXISTS sync_peers (
+		id TEXT PRIMARY KEY,
+		name TEXT,
+		exported_at INTEGER,
+		synced_at INTEGER
+	);
+
+	CREATE TABLE IF NOT EXISTS sync_conflicts (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		product_id INTEGER,
+		path TEXT NOT NULL,
+		field TEXT NOT NULL,
+		local_value TEXT,
+		remote_value TEXT,
+		peer TEXT NOT NULL,
+		kept TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
 	`
 	_, err := DB.Exec(query)
 	if err != nil {
@@ -69,84 +208,114 @@ func createTables() error {
 	// In a real app, use a migration tool. Here we just try to add them and ignore error if exists
 	DB.Exec(`ALTER TABLE products ADD COLUMN image_url TEXT;`) // Ignore error if column exists
 	DB.Exec(`ALTER TABLE products ADD COLUMN shop_name TEXT;`) // Ignore error if column exists
-
-	return nil
-}
-
-// RegisterProduct registers a product if it doesn't exist
-func RegisterProduct(path string, name string) error {
-	// Prevent registering a product inside an existing product folder
-	parentInfo, err := GetParentProductInfo(path)
+	DB.Exec(`ALTER TABLE tags ADD COLUMN parent_id INTEGER REFERENCES tags(id);`)
+	DB.Exec(`ALTER TABLE tags ADD COLUMN color TEXT;`)
+	DB.Exec(`ALTER TABLE tags ADD COLUMN icon TEXT;`)
+	DB.Exec(`ALTER TABLE products ADD COLUMN description TEXT;`)
+	DB.Exec(`ALTER TABLE products ADD COLUMN root_id TEXT;`)
+	DB.Exec(`ALTER TABLE products ADD COLUMN rel_path TEXT;`) // Filled in for existing rows by SyncRoots
+
+	// Tag names are unique among siblings, not globally
+	if err := migrateTagNames(); err != nil {
+		log.Printf("Error migrating tags: %v", err)
+		return err
+	}
+	DB.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_tags_parent_name ON tags(COALESCE(parent_id, 0), name);`)
+
+	// Full "parent/child" path of every tag
+	_, err = DB.Exec(`
+	CREATE VIEW IF NOT EXISTS tag_paths AS
+	WITH RECURSIVE tree(id, path) AS (
+		SELECT id, name FROM tags WHERE parent_id IS NULL
+		UNION ALL
+		SELECT t.id, tree.path || '/' || t.name FROM tags t JOIN tree ON t.parent_id = tree.id
+	)
+	SELECT id, path FROM tree;
+	`)
 	if err != nil {
+		log.Printf("Error creating views: %v", err)
 		return err
 	}
-	if parentInfo != nil {
-		return fmt.Errorf("cannot register product inside existing product: parent=%s", parentInfo.Path)
+
+	if err := createTextIndexTriggers(); err != nil {
+		log.Printf("Error creating search triggers: %v", err)
+		return err
 	}
 
-	query := `INSERT OR IGNORE INTO products (path, name) VALUES (?, ?)`
-	_, err = DB.Exec(query, path, name)
-	return err
+	return nil
 }
 
-// UpdateProduct updates the product information
-func UpdateProduct(path string, url string, imageUrl string, shopName string, tags []string) error {
-	// 1. Update product details
-	query := `UPDATE products SET url = ?, image_url = ?, shop_name = ? WHERE path = ?`
-	if _, err := DB.Exec(query, url, imageUrl, shopName, path); err != nil {
+// migrateTagNames rebuilds a tags table created with a globally unique name
+// column, which SQLite can't drop in place. Foreign keys are switched off on
+// a dedicated connection while the table is swapped.
+func migrateTagNames() error {
+	var schema string
+	if err := DB.QueryRow(`SELECT sql FROM sqlite_master WHERE type = 'table' AND name = 'tags'`).Scan(&schema); err != nil {
 		return err
 	}
+	if !strings.Contains(strings.ToUpper(schema), "UNIQUE") {
+		return nil
+	}
 
-	// 2. Handle Tags
-	// First, get product ID
-	var productId int
-	if err := DB.QueryRow("SELECT id FROM products WHERE path = ?", path).Scan(&productId); err != nil {
+	ctx := context.Background()
+	conn, err := DB.Conn(ctx)
+	if err != nil {
 		return err
 	}
+	defer conn.Close()
 
-	// Clear existing tags for this product
-	if _, err := DB.Exec("DELETE FROM product_tags WHERE product_id = ?", productId); err != nil {
+	if _, err := conn.ExecContext(ctx, `PRAGMA foreign_keys = OFF`); err != nil {
 		return err
 	}
+	defer conn.ExecContext(ctx, `PRAGMA foreign_keys = ON`)
 
-	// Add new tags
-	for _, tagName := range tags {
-		if tagName == "" {
-			continue
-		}
-		if err := AddTag(path, tagName); err != nil {
-			log.Printf("Error adding tag %s: %v", tagName, err)
-			// Continue adding other tags even if one fails
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	statements := []string{
+		`DROP VIEW IF EXISTS tag_paths`, // Recreated by createTables
+		`CREATE TABLE tags_new (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			parent_id INTEGER REFERENCES tags(id),
+			color TEXT,
+			icon TEXT
+		)`,
+		`INSERT INTO tags_new (id, name, parent_id, color, icon) SELECT id, name, parent_id, color, icon FROM tags`,
+		`DROP TABLE tags`,
+		`ALTER TABLE tags_new RENAME TO tags`,
+	}
+	for _, st := range statements {
+		if _, err := tx.Exec(st); err != nil {
+			return err
 		}
 	}
-
-	return nil
+	return tx.Commit()
 }
 
-// AddTag adds a tag to a product
-func AddTag(path string, tagName string) error {
-	// 1. Ensure tag exists
-	tagQuery := `INSERT OR IGNORE INTO tags (name) VALUES (?)`
-	if _, err := DB.Exec(tagQuery, tagName); err != nil {
+// RegisterProduct registers a product if it doesn't exist
+func RegisterProduct(path string, name string) error {
+	// Prevent registering a product inside an existing product folder
+	parentInfo, err := GetParentProductInfo(path)
+	if err != nil {
 		return err
 	}
-
-	// 2. Get product ID
-	var productId int
-	if err := DB.QueryRow("SELECT id FROM products WHERE path = ?", path).Scan(&productId); err != nil {
-		return err
+	if parentInfo != nil {
+		return fmt.Errorf("cannot register product inside existing product: parent=%s", parentInfo.Path)
 	}
 
-	// 3. Get tag ID
-	var tagId int
-	if err := DB.QueryRow("SELECT id FROM tags WHERE name = ?", tagName).Scan(&tagId); err != nil {
+	query := `INSERT OR IGNORE INTO products (path, name) VALUES (?, ?)`
+	result, err := DB.Exec(query, path, name)
+	if err != nil {
 		return err
 	}
-
-	// 4. Link product and tag
-	linkQuery := `INSERT OR IGNORE INTO product_tags (product_id, tag_id) VALUES (?, ?)`
-	_, err := DB.Exec(linkQuery, productId, tagId)
-	return err
+	if n, _ := result.RowsAffected(); n == 0 {
+		return nil
+	}
+	return assignRootTx(DB, path)
 }
 
 type ProductInfo struct {
@@ -155,21 +324,33 @@ type ProductInfo struct {
 	Url      string
 	ImageUrl string
 	ShopName string
-	Tags     []string
+	Tags     []string // Full tag paths, e.g. "衣装/トップス"
+	RootID   string   // Library root containing the product, "" if none
+	RelPath  string   // Path relative to the root, "/"-separated
+	Offline  bool     // The product's root is missing or disabled
 }
 
 // GetProductInfo retrieves information for a product
 func GetProductInfo(path string) (*ProductInfo, error) {
+	return getProductInfo(DB, path)
+}
+
+func getProductInfo(q querier, path string) (*ProductInfo, error) {
 	var info ProductInfo
 	info.Path = path
 
 	// Get basic info
-	query := `SELECT name, url, image_url, shop_name FROM products WHERE path = ?`
+	query := `
+		SELECT p.name, p.url, p.image_url, p.shop_name, p.root_id, p.rel_path, COALESCE(r.online, 1) = 0
+		FROM products p LEFT JOIN library_roots r ON r.id = p.root_id
+		WHERE p.path = ?`
 	var url sql.NullString
 	var imageUrl sql.NullString
 	var shopName sql.NullString
+	var rootID sql.NullString
+	var relPath sql.NullString
 
-	err := DB.QueryRow(query, path).Scan(&info.Name, &url, &imageUrl, &shopName)
+	err := q.QueryRow(query, path).Scan(&info.Name, &url, &imageUrl, &shopName, &rootID, &relPath, &info.Offline)
 	if err == sql.ErrNoRows {
 		return nil, nil // Not found is not an error here, just return nil
 	} else if err != nil {
@@ -185,16 +366,18 @@ func GetProductInfo(path string) (*ProductInfo, error) {
 	if shopName.Valid {
 		info.ShopName = shopName.String
 	}
+	info.RootID = rootID.String
+	info.RelPath = relPath.String
 
 	// Get tags
 	tagQuery := `
-		SELECT t.name 
-		FROM tags t
+		SELECT t.path
+		FROM tag_paths t
 		JOIN product_tags pt ON t.id = pt.tag_id
 		JOIN products p ON p.id = pt.product_id
 		WHERE p.path = ?
 	`
-	rows, err := DB.Query(tagQuery, path)
+	rows, err := q.Query(tagQuery, path)
 	if err != nil {
 		return nil, err
 	}
@@ -238,3 +421,98 @@ func GetParentProductInfo(path string) (*ProductInfo, error) {
 		return info, nil
 	}
 }
+
+// ListProducts returns every registered product with its tags
+func ListProducts() ([]ProductInfo, error) {
+	rows, err := DB.Query(`
+		SELECT p.id, p.path, p.name, p.url, p.image_url, p.shop_name, p.root_id, p.rel_path, COALESCE(r.online, 1) = 0
+		FROM products p LEFT JOIN library_roots r ON r.id = p.root_id
+		ORDER BY p.path`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var products []ProductInfo
+	index := make(map[int]int)
+	for rows.Next() {
+		var id int
+		var info ProductInfo
+		var name, url, imageUrl, shopName, rootID, relPath sql.NullString
+		if err := rows.Scan(&id, &info.Path, &name, &url, &imageUrl, &shopName, &rootID, &relPath, &info.Offline); err != nil {
+			return nil, err
+		}
+		info.Name = name.String
+		info.Url = url.String
+		info.ImageUrl = imageUrl.String
+		info.ShopName = shopName.String
+		info.RootID = rootID.String
+		info.RelPath = relPath.String
+		index[id] = len(products)
+		products = append(products, info)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	tagRows, err := DB.Query(`SELECT pt.product_id, t.path FROM product_tags pt JOIN tag_paths t ON t.id = pt.tag_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer tagRows.Close()
+
+	for tagRows.Next() {
+		var id int
+		var tagName string
+		if err := tagRows.Scan(&id, &tagName); err != nil {
+			return nil, err
+		}
+		if i, ok := index[id]; ok {
+			products[i].Tags = append(products[i].Tags, tagName)
+		}
+	}
+
+	return products, tagRows.Err()
+}
+
+// PathMove is a product folder that was moved on disk
+type PathMove struct {
+	From string
+	To   string
+}
+
+// MoveProductPaths updates the stored paths of moved product folders (and
+// anything recorded below them) in a single transaction
+func MoveProductPaths(moves []PathMove) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, m := range moves {
+		if _, err := tx.Exec(`UPDATE products SET path = ? WHERE path = ?`, m.To, m.From); err != nil {
+			return err
+		}
+		if err := assignRootTx(tx, m.To); err != nil {
+			return err
+		}
+
+		if err := rebaseHashesTx(tx, m.From, m.To); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// rebaseHashesTx updates cached file hashes below folder from to be below to
+func rebaseHashesTx(tx *sql.Tx, from string, to string) error {
+	// SQLite's substr counts characters, not bytes
+	prefix := from + string(filepath.Separator)
+	prefixLen := utf8.RuneCountInString(prefix)
+	// A stale entry already at the new path is replaced
+	query := `UPDATE OR REPLACE file_hashes SET path = ? || substr(path, ?) WHERE substr(path, 1, ?) = ?`
+	_, err := tx.Exec(query, to+string(filepath.Separator), prefixLen+1, prefixLen, prefix)
+	return err
+}
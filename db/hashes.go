@@ -0,0 +1,72 @@
+package db
+
+import (
+	"database/sql"
+)
+
+// FileHash is a cached content hash for a file in the library
+type FileHash struct {
+	Path        string
+	Size        int64
+	ModTime     int64 // UnixNano
+	PartialHash string
+	FullHash    string
+}
+
+// GetFileHash returns the cached hash row for a path, or nil if none exists
+func GetFileHash(path string) (*FileHash, error) {
+	var h FileHash
+	var partial, full sql.NullString
+
+	query := `SELECT path, size, mtime, partial_hash, full_hash FROM file_hashes WHERE path = ?`
+	err := DB.QueryRow(query, path).Scan(&h.Path, &h.Size, &h.ModTime, &partial, &full)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	h.PartialHash = partial.String
+	h.FullHash = full.String
+	return &h, nil
+}
+
+// SaveFileHash inserts or replaces the cached hash row for a path
+func SaveFileHash(h *FileHash) error {
+	query := `
+	INSERT INTO file_hashes (path, size, mtime, partial_hash, full_hash)
+	VALUES (?, ?, ?, ?, ?)
+	ON CONFLICT(path) DO UPDATE SET
+		size = excluded.size,
+		mtime = excluded.mtime,
+		partial_hash = excluded.partial_hash,
+		full_hash = excluded.full_hash
+	`
+	_, err := DB.Exec(query, h.Path, h.Size, h.ModTime, nullIfEmpty(h.PartialHash), nullIfEmpty(h.FullHash))
+	return err
+}
+
+// DeleteFileHash removes the cached hash row for a path
+func DeleteFileHash(path string) error {
+	_, err := DB.Exec(`DELETE FROM file_hashes WHERE path = ?`, path)
+	return err
+}
+
+// IgnoreDuplicateGroup marks a duplicate group (by full hash) as intentionally kept
+func IgnoreDuplicateGroup(hash string) error {
+	_, err := DB.Exec(`INSERT OR IGNORE INTO duplicate_ignores (hash) VALUES (?)`, hash)
+	return err
+}
+
+// IsDuplicateGroupIgnored reports whether a duplicate group was marked as kept
+func IsDuplicateGroupIgnored(hash string) (bool, error) {
+	var count int
+	if err := DB.QueryRow(`SELECT COUNT(*) FROM duplicate_ignores WHERE hash = ?`, hash).Scan(&count); err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func nullIfEmpty(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
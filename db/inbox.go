@@ -0,0 +1,158 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Inbox item statuses
+const (
+	InboxPending  = "pending"  // Waiting for the user to confirm the match
+	InboxFiled    = "filed"    // Moved into the library
+	InboxRejected = "rejected" // The user chose to leave the file in the inbox
+	InboxFailed   = "failed"   // Filing was attempted but failed
+)
+
+// InboxItem is a download seen in the inbox folder
+type InboxItem struct {
+	ID          int64     `json:"id"`
+	Path        string    `json:"path"`
+	Title       string    `json:"title"`
+	ShopName    string    `json:"shopName"`
+	Url         string    `json:"url"`
+	ImageUrl    string    `json:"imageUrl"`
+	Destination string    `json:"destination"`
+	Status      string    `json:"status"`
+	Reason      string    `json:"reason"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// AddInboxItem records a new inbox item and sets its ID
+func AddInboxItem(item *InboxItem) error {
+	query := `
+	INSERT INTO inbox_items (path, title, shop_name, url, image_url, destination, status, reason)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	res, err := DB.Exec(query, item.Path, item.Title, item.ShopName, item.Url, item.ImageUrl, item.Destination, item.Status, item.Reason)
+	if err != nil {
+		return err
+	}
+	item.ID, err = res.LastInsertId()
+	return err
+}
+
+// UpdateInboxItem saves the path and mutable fields of an inbox item
+func UpdateInboxItem(item *InboxItem) error {
+	query := `
+	UPDATE inbox_items
+	SET path = ?, title = ?, shop_name = ?, url = ?, image_url = ?, destination = ?, status = ?, reason = ?
+	WHERE id = ?
+	`
+	_, err := DB.Exec(query, item.Path, item.Title, item.ShopName, item.Url, item.ImageUrl, item.Destination, item.Status, item.Reason, item.ID)
+	return err
+}
+
+// GetInboxItem returns an inbox item by ID, or nil if it doesn't exist
+func GetInboxItem(id int64) (*InboxItem, error) {
+	rows, err := DB.Query(inboxSelect+` WHERE id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	items, err := scanInboxItems(rows)
+	if err != nil || len(items) == 0 {
+		return nil, err
+	}
+	return &items[0], nil
+}
+
+// IsInboxPathKnown reports whether a file in the inbox is already pending, was rejected
+// or failed to file, so the watcher doesn't process it again
+func IsInboxPathKnown(path string) (bool, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM inbox_items WHERE path = ? AND status IN (?, ?, ?)`
+	if err := DB.QueryRow(query, path, InboxPending, InboxRejected, InboxFailed).Scan(&count); err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// RelocateInboxItem follows an undone or redone filing: the item at from is
+// now at to with the given status. Files that didn't come from the inbox
+// match no item.
+func RelocateInboxItem(from string, to string, status string, reason string) error {
+	_, err := DB.Exec(`UPDATE inbox_items SET path = ?, status = ?, reason = ? WHERE path = ?`, to, status, reason, from)
+	return err
+}
+
+// ListInboxItems returns inbox items with the given status, newest first.
+// An empty status returns all items.
+func ListInboxItems(status string) ([]InboxItem, error) {
+	var rows *sql.Rows
+	var err error
+	if status == "" {
+		rows, err = DB.Query(inboxSelect + ` ORDER BY id DESC`)
+	} else {
+		rows, err = DB.Query(inboxSelect+` WHERE status = ? ORDER BY id DESC`, status)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return scanInboxItems(rows)
+}
+
+const inboxSelect = `
+	SELECT id, path, title, shop_name, url, image_url, destination, status, reason, created_at
+	FROM inbox_items`
+
+func scanInboxItems(rows *sql.Rows) ([]InboxItem, error) {
+	defer rows.Close()
+
+	var items []InboxItem
+	for rows.Next() {
+		var item InboxItem
+		var title, shopName, url, imageUrl, destination, reason sql.NullString
+		if err := rows.Scan(&item.ID, &item.Path, &title, &shopName, &url, &imageUrl, &destination, &item.Status, &reason, &item.CreatedAt); err != nil {
+			return nil, err
+		}
+		item.Title = title.String
+		item.ShopName = shopName.String
+		item.Url = url.String
+		item.ImageUrl = imageUrl.String
+		item.Destination = destination.String
+		item.Reason = reason.String
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// FindProductByName returns the first product whose name matches exactly, or nil
+func FindProductByName(name string) (*ProductInfo, error) {
+	var path string
+	err := DB.QueryRow(`SELECT path FROM products WHERE name = ? ORDER BY id LIMIT 1`, name).Scan(&path)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return GetProductInfo(path)
+}
+
+// AddInboxGroupFolders records folders the inbox created to group filed
+// products, such as the shop folder of "{shop}/{title}"
+func AddInboxGroupFolders(paths []string) error {
+	for _, path := range paths {
+		if _, err := DB.Exec(`INSERT OR IGNORE INTO inbox_group_folders (path) VALUES (?)`, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IsInboxGroupFolder reports whether the inbox created path to group products
+func IsInboxGroupFolder(path string) (bool, error) {
+	var count int
+	if err := DB.QueryRow(`SELECT COUNT(*) FROM inbox_group_folders WHERE path = ?`, path).Scan(&count); err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
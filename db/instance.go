@@ -0,0 +1,59 @@
+package db
+
+import (
+	"aslm/config"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// instancePath is the file the running app writes its process ID to, so
+// other processes (e.g. "aslm backup restore") can tell the database is in use
+func instancePath() string {
+	return filepath.Join(config.DataDir(), "aslm.pid")
+}
+
+// ClaimInstance marks the database as in use by this process until the
+// returned release function is called
+func ClaimInstance() (func(), error) {
+	pid := strconv.Itoa(os.Getpid())
+	if err := os.WriteFile(instancePath(), []byte(pid), 0644); err != nil {
+		return nil, err
+	}
+	return func() {
+		// Leave the file alone if another instance has claimed it since
+		if data, err := os.ReadFile(instancePath()); err == nil && string(data) == pid {
+			os.Remove(instancePath())
+		}
+	}, nil
+}
+
+// RunningInstance returns the process ID of another live process that
+// claimed the database, or 0. A file left behind by a crash is ignored.
+func RunningInstance() int {
+	data, err := os.ReadFile(instancePath())
+	if err != nil {
+		return 0
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid == os.Getpid() || !processAlive(pid) {
+		return 0
+	}
+	return pid
+}
+
+func processAlive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		// FindProcess opens a handle, which fails once the process has exited
+		p.Release()
+		return true
+	}
+	return p.Signal(syscall.Signal(0)) == nil
+}
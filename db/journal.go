@@ -0,0 +1,223 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Operation kinds
+const (
+	OpMetadata = "metadata"  // Product url/image/shop/tags changed
+	OpMove     = "move"      // Product folder moved
+	OpFileMove = "file_move" // A single file moved (e.g. filed from the inbox)
+	OpTagName  = "tag_name"  // A tag was renamed
+)
+
+// Operation is a single journaled change. Before and After are JSON
+// snapshots: a ProductSnapshot for metadata, a MoveSnapshot for moves and a
+// TagSnapshot for tag renames.
+type Operation struct {
+	ID        int64     `json:"id"`
+	BatchID   string    `json:"batchId"`
+	Kind      string    `json:"kind"`
+	ProductID int64     `json:"productId"`
+	Path      string    `json:"path"`
+	Before    string    `json:"before"`
+	After     string    `json:"after"`
+	Undone    bool      `json:"undone"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// ProductSnapshot is the editable metadata of a product at one point in time
+type ProductSnapshot struct {
+	Url      string   `json:"url"`
+	ImageUrl string   `json:"imageUrl"`
+	ShopName string   `json:"shopName"`
+	Tags     []string `json:"tags"`
+}
+
+// MoveSnapshot is the location of a moved folder or file
+type MoveSnapshot struct {
+	Path string `json:"path"`
+}
+
+// TagSnapshot is the name of a tag at one point in time
+type TagSnapshot struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+// NewBatchID returns an ID grouping operations that are undone together
+func NewBatchID() string {
+	return fmt.Sprintf("%d", time.Now().UnixNano())
+}
+
+// RecordOperation appends an operation to the journal. Recording a new
+// operation discards everything that was undone (the redo stack).
+func RecordOperation(op *Operation) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := recordOperationTx(tx, op); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func recordOperationTx(tx *sql.Tx, op *Operation) error {
+	if _, err := tx.Exec(`DELETE FROM operations WHERE undone = 1`); err != nil {
+		return err
+	}
+
+	query := `INSERT INTO operations (batch_id, kind, product_id, path, before, after) VALUES (?, ?, ?, ?, ?, ?)`
+	res, err := tx.Exec(query, op.BatchID, op.Kind, sql.NullInt64{Int64: op.ProductID, Valid: op.ProductID != 0}, op.Path, op.Before, op.After)
+	if err != nil {
+		return err
+	}
+	op.ID, err = res.LastInsertId()
+	return err
+}
+
+// RecordMove journals a moved product folder (productID != 0) or file
+func RecordMove(batchID string, productID int64, from string, to string) error {
+	kind := OpFileMove
+	if productID != 0 {
+		kind = OpMove
+	}
+	before, _ := json.Marshal(MoveSnapshot{Path: from})
+	after, _ := json.Marshal(MoveSnapshot{Path: to})
+	return RecordOperation(&Operation{
+		BatchID:   batchID,
+		Kind:      kind,
+		ProductID: productID,
+		Path:      to,
+		Before:    string(before),
+		After:     string(after),
+	})
+}
+
+// LatestBatch returns the operations of the most recent batch that can be
+// undone (undone=false) or, for redo, the most recently undone batch
+// (undone=true). It returns nil if there is nothing to undo or redo.
+func LatestBatch(undone bool) ([]Operation, error) {
+	var batchID string
+	var query string
+	if undone {
+		// Batches are undone newest first, so the last one undone has the lowest ID
+		query = `SELECT batch_id FROM operations WHERE undone = 1 ORDER BY id ASC LIMIT 1`
+	} else {
+		query = `SELECT batch_id FROM operations WHERE undone = 0 ORDER BY id DESC LIMIT 1`
+	}
+	err := DB.QueryRow(query).Scan(&batchID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	rows, err := DB.Query(operationSelect+` WHERE batch_id = ? ORDER BY id ASC`, batchID)
+	if err != nil {
+		return nil, err
+	}
+	return scanOperations(rows)
+}
+
+// SetBatchUndone marks every operation in a batch as undone or redone
+func SetBatchUndone(batchID string, undone bool) error {
+	_, err := DB.Exec(`UPDATE operations SET undone = ? WHERE batch_id = ?`, undone, batchID)
+	return err
+}
+
+// ProductHistory returns the journal of a product, newest first
+func ProductHistory(path string) ([]Operation, error) {
+	var productId int64
+	err := DB.QueryRow(`SELECT id FROM products WHERE path = ?`, path).Scan(&productId)
+	if err == sql.ErrNoRows {
+		rows, err := DB.Query(operationSelect+` WHERE path = ? ORDER BY id DESC`, path)
+		if err != nil {
+			return nil, err
+		}
+		return scanOperations(rows)
+	} else if err != nil {
+		return nil, err
+	}
+
+	rows, err := DB.Query(operationSelect+` WHERE product_id = ? ORDER BY id DESC`, productId)
+	if err != nil {
+		return nil, err
+	}
+	return scanOperations(rows)
+}
+
+// GetProductID returns the ID of the product at path, or 0 if it isn't registered
+func GetProductID(path string) (int64, error) {
+	var productId int64
+	err := DB.QueryRow(`SELECT id FROM products WHERE path = ?`, path).Scan(&productId)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return productId, err
+}
+
+// GetProductPath returns the current path of a product by ID
+func GetProductPath(productID int64) (string, error) {
+	var path string
+	err := DB.QueryRow(`SELECT path FROM products WHERE id = ?`, productID).Scan(&path)
+	return path, err
+}
+
+// ApplyProductSnapshot restores product metadata without journaling it (used by undo/redo)
+func ApplyProductSnapshot(path string, snapshot ProductSnapshot) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	patch := ProductPatch{
+		Url:      &snapshot.Url,
+		ImageUrl: &snapshot.ImageUrl,
+		ShopName: &snapshot.ShopName,
+		Tags:     &snapshot.Tags,
+	}
+	if err := patchProductTx(tx, path, patch, ""); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// normalizeTags returns a non-nil copy so snapshots compare equal regardless of tag order
+func normalizeTags(tags []string) []string {
+	result := append([]string{}, tags...)
+	sort.Strings(result)
+	return result
+}
+
+const operationSelect = `
+	SELECT id, batch_id, kind, product_id, path, before, after, undone, created_at
+	FROM operations`
+
+func scanOperations(rows *sql.Rows) ([]Operation, error) {
+	defer rows.Close()
+
+	var ops []Operation
+	for rows.Next() {
+		var op Operation
+		var productId sql.NullInt64
+		var before, after sql.NullString
+		if err := rows.Scan(&op.ID, &op.BatchID, &op.Kind, &productId, &op.Path, &before, &after, &op.Undone, &op.CreatedAt); err != nil {
+			return nil, err
+		}
+		op.ProductID = productId.Int64
+		op.Before = before.String
+		op.After = after.String
+		ops = append(ops, op)
+	}
+	return ops, rows.Err()
+}
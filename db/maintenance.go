@@ -0,0 +1,162 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+)
+
+// IntegrityReport is the result of CheckIntegrity
+type IntegrityReport struct {
+	OK          bool     `json:"ok"`
+	Problems    []string `json:"problems"`    // From integrity_check, e.g. "row 12 missing from index ..."
+	ForeignKeys []string `json:"foreignKeys"` // Rows referencing missing rows, e.g. "product_tags row 3 references missing products"
+}
+
+// CheckIntegrity runs SQLite's integrity check (or the faster quick check if
+// full is false) and foreign key check
+func CheckIntegrity(full bool) (*IntegrityReport, error) {
+	return checkIntegrity(DB, full)
+}
+
+func checkIntegrity(q querier, full bool) (*IntegrityReport, error) {
+	report := &IntegrityReport{Problems: []string{}, ForeignKeys: []string{}}
+
+	pragma := `PRAGMA quick_check`
+	if full {
+		pragma = `PRAGMA integrity_check`
+	}
+	rows, err := q.Query(pragma)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if line != "ok" {
+			report.Problems = append(report.Problems, line)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = q.Query(`PRAGMA foreign_key_check`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var table, parent string
+		var rowID sql.NullInt64
+		var fkid int
+		if err := rows.Scan(&table, &rowID, &parent, &fkid); err != nil {
+			return nil, err
+		}
+		row := table
+		if rowID.Valid {
+			row = fmt.Sprintf("%s row %d", table, rowID.Int64)
+		}
+		report.ForeignKeys = append(report.ForeignKeys, fmt.Sprintf("%s references missing %s", row, parent))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	report.OK = len(report.Problems) == 0 && len(report.ForeignKeys) == 0
+	return report, nil
+}
+
+// Backup writes a consistent copy of the database to dest, which must not
+// exist. It runs online: other connections can keep reading and writing.
+//
+// The pure-Go driver doesn't expose SQLite's backup API, so this uses
+// VACUUM INTO, which also produces a snapshot from a single read transaction
+// and compacts the copy.
+func Backup(dest string) error {
+	_, err := DB.Exec(`VACUUM INTO ?`, dest)
+	return err
+}
+
+// Restore replaces the database with the copy at src after checking it, and
+// reopens it. The copy must pass the integrity check.
+func Restore(src string) error {
+	if err := CheckFile(src); err != nil {
+		return err
+	}
+
+	path := Path()
+	tmp := path + ".restore"
+	if err := copyFile(src, tmp); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	// Fold the WAL into the main file and close, so no connection writes
+	// to the file while it is replaced
+	DB.Exec(`PRAGMA wal_checkpoint(TRUNCATE)`)
+	if err := DB.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	os.Remove(path + "-wal")
+	os.Remove(path + "-shm")
+
+	renameErr := os.Rename(tmp, path)
+	if renameErr != nil {
+		os.Remove(tmp)
+	}
+	// Reopen even if the rename failed, so the app keeps working
+	if err := InitDB(); err != nil {
+		return err
+	}
+	return renameErr
+}
+
+// CheckFile opens the database file at path on its own and runs the full check
+func CheckFile(path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+	other, err := sql.Open("sqlite", path)
+	if err != nil {
+		return err
+	}
+	defer other.Close()
+
+	report, err := checkIntegrity(other, true)
+	if err != nil {
+		return fmt.Errorf("%s is not a readable database: %w", path, err)
+	}
+	if !report.OK {
+		return fmt.Errorf("%s failed the integrity check: %d problems", path, len(report.Problems)+len(report.ForeignKeys))
+	}
+	return nil
+}
+
+func copyFile(src string, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
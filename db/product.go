@@ -0,0 +1,258 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// sqliteConstraint is SQLITE_CONSTRAINT; extended codes share the low byte
+const sqliteConstraint = 19
+
+var (
+	// ErrNotFound is returned when a product path is not registered
+	ErrNotFound = errors.New("product not found")
+	// ErrConstraint is returned when a change violates a database constraint
+	ErrConstraint = errors.New("constraint violation")
+)
+
+// ProductPatch is a partial update of a product. Nil fields are left unchanged.
+type ProductPatch struct {
+	Url        *string   `json:"url,omitempty"`
+	ImageUrl   *string   `json:"imageUrl,omitempty"`
+	ShopName   *string   `json:"shopName,omitempty"`
+	Tags       *[]string `json:"tags,omitempty"` // Replaces all tags
+	AddTags    []string  `json:"addTags,omitempty"`
+	RemoveTags []string  `json:"removeTags,omitempty"`
+
+	// Times are recorded as the modification times of the changed fields
+	// instead of now, e.g. for values taken from a synced catalog
+	Times map[string]time.Time `json:"-"`
+}
+
+// Editable fields, as named in ProductSnapshot and product_field_times
+const (
+	FieldURL      = "url"
+	FieldImageURL = "imageUrl"
+	FieldShopName = "shopName"
+	FieldTags     = "tags"
+)
+
+// UpdateProduct replaces all editable fields of a product in one transaction
+func UpdateProduct(path string, url string, imageUrl string, shopName string, tags []string) error {
+	return PatchProduct(path, ProductPatch{
+		Url:      &url,
+		ImageUrl: &imageUrl,
+		ShopName: &shopName,
+		Tags:     &tags,
+	})
+}
+
+// AddTag adds a tag to a product
+func AddTag(path string, tagName string) error {
+	return PatchProduct(path, ProductPatch{AddTags: []string{tagName}})
+}
+
+// PatchProduct applies a partial update to a product. The whole change,
+// including the operation journal entry, is applied in a single transaction:
+// either everything is saved or nothing is. It returns ErrNotFound if the
+// path isn't registered.
+func PatchProduct(path string, patch ProductPatch) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := patchProductTx(tx, path, patch, NewBatchID()); err != nil {
+		return err
+	}
+	return wrapError(tx.Commit())
+}
+
+// patchProductTx applies a patch inside tx. The change is journaled under
+// batchID unless batchID is empty.
+func patchProductTx(tx *sql.Tx, path string, patch ProductPatch, batchID string) error {
+	productId, before, err := snapshotProductTx(tx, path)
+	if err != nil {
+		return err
+	}
+	if productId == 0 {
+		return fmt.Errorf("%w: %s", ErrNotFound, path)
+	}
+
+	if err := applyPatchTx(tx, productId, patch); err != nil {
+		return err
+	}
+
+	_, after, err := snapshotProductTx(tx, path)
+	if err != nil {
+		return err
+	}
+	if before == after {
+		return nil
+	}
+	if err := stampFieldsTx(tx, productId, before, after, patch.Times); err != nil {
+		return err
+	}
+
+	if batchID == "" {
+		return nil
+	}
+	return wrapError(recordOperationTx(tx, &Operation{
+		BatchID:   batchID,
+		Kind:      OpMetadata,
+		ProductID: productId,
+		Path:      path,
+		Before:    before,
+		After:     after,
+	}))
+}
+
+func applyPatchTx(tx *sql.Tx, productId int64, patch ProductPatch) error {
+	fields := []struct {
+		column string
+		value  *string
+	}{
+		{"url", patch.Url},
+		{"image_url", patch.ImageUrl},
+		{"shop_name", patch.ShopName},
+	}
+	for _, f := range fields {
+		if f.value == nil {
+			continue
+		}
+		query := `UPDATE products SET ` + f.column + ` = ? WHERE id = ?`
+		if _, err := tx.Exec(query, *f.value, productId); err != nil {
+			return wrapError(err)
+		}
+	}
+
+	if patch.Tags != nil {
+		if _, err := tx.Exec(`DELETE FROM product_tags WHERE product_id = ?`, productId); err != nil {
+			return wrapError(err)
+		}
+		for _, tagName := range *patch.Tags {
+			if err := linkTagTx(tx, productId, tagName); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, tagName := range patch.AddTags {
+		if err := linkTagTx(tx, productId, tagName); err != nil {
+			return err
+		}
+	}
+
+	for _, tagName := range patch.RemoveTags {
+		tagId, err := resolveTagTx(tx, tagName, false)
+		if err != nil {
+			return err
+		}
+		if tagId == 0 {
+			continue
+		}
+		query := `DELETE FROM product_tags WHERE product_id = ? AND tag_id = ?`
+		if _, err := tx.Exec(query, productId, tagId); err != nil {
+			return wrapError(err)
+		}
+	}
+
+	return nil
+}
+
+// linkTagTx resolves the tag by name, alias or "parent/child" path, creating
+// it if needed, and links it to the product. Empty names are ignored.
+func linkTagTx(tx *sql.Tx, productId int64, tagName string) error {
+	tagId, err := resolveTagTx(tx, tagName, true)
+	if err != nil || tagId == 0 {
+		return err
+	}
+
+	_, err = tx.Exec(`INSERT OR IGNORE INTO product_tags (product_id, tag_id) VALUES (?, ?)`, productId, tagId)
+	return wrapError(err)
+}
+
+// stampFieldsTx records when each field that differs between the before and
+// after snapshots was modified, so synced catalogs can merge field by field
+func stampFieldsTx(tx *sql.Tx, productId int64, before string, after string, times map[string]time.Time) error {
+	var b, a ProductSnapshot
+	if err := json.Unmarshal([]byte(before), &b); err != nil {
+		return err
+	}
+	if err := json.Unmarshal([]byte(after), &a); err != nil {
+		return err
+	}
+
+	changed := map[string]bool{
+		FieldURL:      b.Url != a.Url,
+		FieldImageURL: b.ImageUrl != a.ImageUrl,
+		FieldShopName: b.ShopName != a.ShopName,
+		FieldTags:     !slices.Equal(b.Tags, a.Tags),
+	}
+	now := time.Now()
+	for field, ok := range changed {
+		if !ok {
+			continue
+		}
+		modified, ok := times[field]
+		if !ok {
+			modified = now
+		}
+		if modified.IsZero() {
+			// Unknown time, e.g. a value filled in from a catalog without times
+			if _, err := tx.Exec(`DELETE FROM product_field_times WHERE product_id = ? AND field = ?`, productId, field); err != nil {
+				return wrapError(err)
+			}
+			continue
+		}
+		query := `
+		INSERT INTO product_field_times (product_id, field, modified_at) VALUES (?, ?, ?)
+		ON CONFLICT(product_id, field) DO UPDATE SET modified_at = excluded.modified_at`
+		if _, err := tx.Exec(query, productId, field, modified.UnixNano()); err != nil {
+			return wrapError(err)
+		}
+	}
+	return nil
+}
+
+// snapshotProductTx returns the product ID and a JSON snapshot of its metadata.
+// The ID is 0 if the product is not registered.
+func snapshotProductTx(q querier, path string) (int64, string, error) {
+	var productId int64
+	err := q.QueryRow(`SELECT id FROM products WHERE path = ?`, path).Scan(&productId)
+	if err == sql.ErrNoRows {
+		return 0, "", nil
+	} else if err != nil {
+		return 0, "", err
+	}
+
+	info, err := getProductInfo(q, path)
+	if err != nil {
+		return 0, "", err
+	}
+
+	data, err := json.Marshal(ProductSnapshot{
+		Url:      info.Url,
+		ImageUrl: info.ImageUrl,
+		ShopName: info.ShopName,
+		Tags:     normalizeTags(info.Tags),
+	})
+	if err != nil {
+		return 0, "", err
+	}
+	return productId, string(data), nil
+}
+
+// wrapError maps SQLite constraint failures to ErrConstraint
+func wrapError(err error) error {
+	var coded interface{ Code() int }
+	if err != nil && errors.As(err, &coded) && coded.Code()&0xff == sqliteConstraint {
+		return fmt.Errorf("%w: %v", ErrConstraint, err)
+	}
+	return err
+}
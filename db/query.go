@@ -0,0 +1,157 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ProductQuery filters products. Empty fields match everything; all set
+// fields must match.
+type ProductQuery struct {
+	Text     string   `json:"text,omitempty"`     // Substring of the name or path
+	Tags     []string `json:"tags,omitempty"`     // Every tag must be present; a parent tag also matches its children, and aliases and bare names resolve like when tagging
+	Shop     string   `json:"shop,omitempty"`     // Exact shop name
+	Untagged bool     `json:"untagged,omitempty"` // Only products without any tag
+}
+
+// SavedQuery is a named ProductQuery
+type SavedQuery struct {
+	Name  string       `json:"name"`
+	Query ProductQuery `json:"query"`
+}
+
+// FindProducts returns the products matching q, ordered by path
+func FindProducts(q ProductQuery) ([]ProductInfo, error) {
+	tags := make([]string, len(q.Tags))
+	for i, want := range q.Tags {
+		path, err := tagFilterPath(want)
+		if err != nil {
+			return nil, err
+		}
+		tags[i] = path
+	}
+	q.Tags = tags
+
+	products, err := ListProducts()
+	if err != nil {
+		return nil, err
+	}
+
+	var result []ProductInfo
+	for _, p := range products {
+		if q.Matches(p) {
+			result = append(result, p)
+		}
+	}
+	return result, nil
+}
+
+// Matches reports whether p satisfies the query. Tags are compared with the
+// product's tag paths as given; FindProducts resolves them first.
+func (q ProductQuery) Matches(p ProductInfo) bool {
+	if text := strings.ToLower(strings.TrimSpace(q.Text)); text != "" {
+		if !strings.Contains(strings.ToLower(p.Name), text) && !strings.Contains(strings.ToLower(p.Path), text) {
+			return false
+		}
+	}
+	if q.Shop != "" && p.ShopName != q.Shop {
+		return false
+	}
+	if q.Untagged && len(p.Tags) > 0 {
+		return false
+	}
+	for _, want := range q.Tags {
+		if !hasTag(p.Tags, want) {
+			return false
+		}
+	}
+	return true
+}
+
+// tagFilterPath returns the full path of the tag a filter names, resolving
+// aliases and bare names of nested tags the way tagging does. A name that
+// isn't a tag is returned as given and matches nothing.
+func tagFilterPath(want string) (string, error) {
+	tagID, err := resolveTagTx(DB, want, false)
+	if err != nil || tagID == 0 {
+		return strings.TrimPrefix(strings.TrimSpace(want), "/"), err
+	}
+	var path string
+	if err := DB.QueryRow(`SELECT path FROM tag_paths WHERE id = ?`, tagID).Scan(&path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+func hasTag(tags []string, want string) bool {
+	want = strings.ToLower(strings.TrimSpace(want))
+	for _, tag := range tags {
+		tag = strings.ToLower(tag)
+		if tag == want || strings.HasPrefix(tag, want+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// SaveQuery stores a query under name, replacing any query with that name
+func SaveQuery(name string, q ProductQuery) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return fmt.Errorf("query name is empty")
+	}
+	data, err := json.Marshal(q)
+	if err != nil {
+		return err
+	}
+	_, err = DB.Exec(`INSERT OR REPLACE INTO saved_queries (name, query) VALUES (?, ?)`, name, string(data))
+	return err
+}
+
+// GetSavedQuery returns a saved query by name
+func GetSavedQuery(name string) (*ProductQuery, error) {
+	var data string
+	err := DB.QueryRow(`SELECT query FROM saved_queries WHERE name = ?`, name).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("saved query not found: %s", name)
+	} else if err != nil {
+		return nil, err
+	}
+
+	var q ProductQuery
+	if err := json.Unmarshal([]byte(data), &q); err != nil {
+		return nil, err
+	}
+	return &q, nil
+}
+
+// ListSavedQueries returns all saved queries ordered by name
+func ListSavedQueries() ([]SavedQuery, error) {
+	rows, err := DB.Query(`SELECT name, query FROM saved_queries ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var queries []SavedQuery
+	for rows.Next() {
+		var sq SavedQuery
+		var data string
+		if err := rows.Scan(&sq.Name, &data); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(data), &sq.Query); err != nil {
+			return nil, err
+		}
+		queries = append(queries, sq)
+	}
+	return queries, rows.Err()
+}
+
+// DeleteSavedQuery removes a saved query
+func DeleteSavedQuery(name string) error {
+	_, err := DB.Exec(`DELETE FROM saved_queries WHERE name = ?`, name)
+	return err
+}
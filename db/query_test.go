@@ -0,0 +1,84 @@
+package db
+
+import (
+	"aslm/config"
+	"path/filepath"
+	"testing"
+)
+
+func openTestDB(t *testing.T) {
+	t.Helper()
+	config.SetDataDir(t.TempDir())
+	if err := InitDB(); err != nil {
+		t.Fatalf("InitDB: %v", err)
+	}
+	t.Cleanup(func() { DB.Close() })
+}
+
+func TestFindProductsResolvesTagFilters(t *testing.T) {
+	openTestDB(t)
+
+	dir := t.TempDir()
+	hat := filepath.Join(dir, "Hat")
+	shoes := filepath.Join(dir, "Shoes")
+	for _, path := range []string{hat, shoes} {
+		if err := RegisterProduct(path, filepath.Base(path)); err != nil {
+			t.Fatalf("RegisterProduct: %v", err)
+		}
+	}
+	if err := UpdateProduct(hat, "", "", "", []string{"clothes/hat"}); err != nil {
+		t.Fatalf("UpdateProduct: %v", err)
+	}
+	if err := UpdateProduct(shoes, "", "", "", []string{"clothes/shoes"}); err != nil {
+		t.Fatalf("UpdateProduct: %v", err)
+	}
+	hatTag, err := CreateTag("clothes/hat")
+	if err != nil {
+		t.Fatalf("CreateTag: %v", err)
+	}
+	if err := AddTagAlias(hatTag, "帽子"); err != nil {
+		t.Fatalf("AddTagAlias: %v", err)
+	}
+	clothesTag, err := CreateTag("clothes")
+	if err != nil {
+		t.Fatalf("CreateTag: %v", err)
+	}
+	if err := AddTagAlias(clothesTag, "outfit"); err != nil {
+		t.Fatalf("AddTagAlias: %v", err)
+	}
+
+	cases := []struct {
+		tag  string
+		want []string
+	}{
+		{"clothes/hat", []string{hat}},
+		{"/clothes/hat", []string{hat}},
+		{"hat", []string{hat}},            // Bare name of a nested tag
+		{"帽子", []string{hat}},             // Alias
+		{"clothes", []string{hat, shoes}}, // Parent
+		{"outfit", []string{hat, shoes}},  // Alias of a parent
+		{"outfit/hat", []string{hat}},
+		{"bags", nil},
+	}
+	for _, tc := range cases {
+		products, err := FindProducts(ProductQuery{Tags: []string{tc.tag}})
+		if err != nil {
+			t.Errorf("FindProducts(%q): %v", tc.tag, err)
+			continue
+		}
+		var got []string
+		for _, p := range products {
+			got = append(got, p.Path)
+		}
+		if len(got) != len(tc.want) {
+			t.Errorf("FindProducts(%q) = %v, want %v", tc.tag, got, tc.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tc.want[i] {
+				t.Errorf("FindProducts(%q) = %v, want %v", tc.tag, got, tc.want)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,298 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// RootState is a library root as last seen on disk
+type RootState struct {
+	ID     string
+	Path   string
+	Online bool // false if the drive or mount is missing, or the root is disabled
+}
+
+// RootSync is what SyncRoots changed
+type RootSync struct {
+	Moved      []PathMove      // Roots whose folder is at a new path
+	Collisions []PathCollision // Products that couldn't follow their root
+}
+
+// PathCollision is a product that stayed at its old path because another
+// product is already registered where its root moved it
+type PathCollision struct {
+	Path    string `json:"path"`
+	NewPath string `json:"newPath"`
+}
+
+// SyncRoots replaces the known library roots and resolves product paths
+// against them. Products store their root ID and a path relative to it, so
+// when a root's path changes (e.g. a removable drive got a new letter, or the
+// database is used on another machine) its products follow it, along with
+// the cached hashes, inbox items and group folders, journal entries and sync
+// conflicts recorded below the root. A product whose new path is already
+// taken keeps its old path and is reported in Collisions. Afterwards every
+// other product is reassigned to the root containing it (the deepest one if
+// roots are nested) and its relative path recomputed; products outside all
+// roots get no root. This also migrates rows that only have an absolute path.
+func SyncRoots(roots []RootState) (*RootSync, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	oldPaths := make(map[string]string)
+	rows, err := tx.Query(`SELECT id, path FROM library_roots`)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var id, path string
+		if err := rows.Scan(&id, &path); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		oldPaths[id] = path
+	}
+	rows.Close()
+
+	if _, err := tx.Exec(`DELETE FROM library_roots`); err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	for _, r := range roots {
+		query := `INSERT OR REPLACE INTO library_roots (id, path, online, checked_at) VALUES (?, ?, ?, ?)`
+		if _, err := tx.Exec(query, r.ID, filepath.Clean(r.Path), r.Online, now); err != nil {
+			return nil, wrapError(err)
+		}
+	}
+
+	result := &RootSync{}
+	sep := string(filepath.Separator)
+	blocked, err := followRootsTx(tx, sep)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range roots {
+		if old, ok := oldPaths[r.ID]; ok && old != filepath.Clean(r.Path) {
+			move := PathMove{From: old, To: filepath.Clean(r.Path)}
+			if err := rebasePathsTx(tx, move.From, move.To); err != nil {
+				return nil, err
+			}
+			result.Moved = append(result.Moved, move)
+		}
+	}
+
+	// Blocked products keep their root and relative path, so they follow
+	// the root on a later sync once the other product is gone
+	args := []any{sep}
+	exclude := ""
+	for _, b := range blocked {
+		result.Collisions = append(result.Collisions, PathCollision{Path: b.from, NewPath: b.to})
+		args = append(args, b.id)
+		exclude += ", ?"
+	}
+	where := ``
+	if exclude != "" {
+		where = ` WHERE id NOT IN (` + exclude[2:] + `)`
+	}
+	if _, err := tx.Exec(`UPDATE products SET root_id = (`+rootOfQuery+`)`+where, args...); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(`UPDATE products SET rel_path = (`+relPathQuery+`)`+where, args...); err != nil {
+		return nil, err
+	}
+	return result, tx.Commit()
+}
+
+// rootMove is a product following its root
+type rootMove struct {
+	id       int64
+	from, to string
+}
+
+// followRootsTx moves every product to the path its root and relative path
+// give. Products are moved as long as any can be, so two products trading
+// places don't collide; the ones returned are blocked by another product.
+func followRootsTx(tx *sql.Tx, sep string) ([]rootMove, error) {
+	rows, err := tx.Query(`
+		SELECT p.id, p.path, CASE p.rel_path WHEN '' THEN r.path ELSE r.path || ?1 || replace(p.rel_path, '/', ?1) END
+		FROM products p JOIN library_roots r ON r.id = p.root_id
+		WHERE p.rel_path IS NOT NULL`, sep)
+	if err != nil {
+		return nil, err
+	}
+	var moves []rootMove
+	for rows.Next() {
+		var m rootMove
+		if err := rows.Scan(&m.id, &m.from, &m.to); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if m.from != m.to {
+			moves = append(moves, m)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for progress := true; progress && len(moves) > 0; {
+		progress = false
+		var blocked []rootMove
+		for _, m := range moves {
+			var taken int
+			if err := tx.QueryRow(`SELECT COUNT(*) FROM products WHERE path = ?`, m.to).Scan(&taken); err != nil {
+				return nil, err
+			}
+			if taken > 0 {
+				blocked = append(blocked, m)
+				continue
+			}
+			if _, err := tx.Exec(`UPDATE products SET path = ? WHERE id = ?`, m.to, m.id); err != nil {
+				return nil, wrapError(err)
+			}
+			progress = true
+		}
+		moves = blocked
+	}
+	return moves, nil
+}
+
+// rebasePathsTx rewrites every stored path at or below the folder from to be
+// below to instead, for a root that moved as a whole. Products are handled by
+// followRootsTx.
+func rebasePathsTx(tx *sql.Tx, from string, to string) error {
+	if err := rebaseHashesTx(tx, from, to); err != nil {
+		return err
+	}
+
+	columns := []struct{ table, column string }{
+		{"inbox_items", "path"},
+		{"inbox_items", "destination"},
+		{"operations", "path"},
+		{"sync_conflicts", "path"},
+		{"inbox_group_folders", "path"},
+	}
+	// SQLite's substr counts characters, not bytes
+	prefix := from + string(filepath.Separator)
+	prefixLen := utf8.RuneCountInString(prefix)
+	for _, c := range columns {
+		query := `UPDATE ` + c.table + ` SET ` + c.column + ` = CASE WHEN ` + c.column + ` = ?1 THEN ?2 ELSE ?3 || substr(` + c.column + `, ?4) END
+			WHERE ` + c.column + ` = ?1 OR substr(` + c.column + `, 1, ?5) = ?6`
+		if _, err := tx.Exec(query, from, to, to+string(filepath.Separator), prefixLen+1, prefixLen, prefix); err != nil {
+			return wrapError(err)
+		}
+	}
+
+	// Journaled moves keep their paths in JSON snapshots
+	rows, err := tx.Query(`SELECT id, before, after FROM operations WHERE kind IN (?, ?)`, OpMove, OpFileMove)
+	if err != nil {
+		return err
+	}
+	type snapshots struct {
+		id            int64
+		before, after string
+	}
+	var changed []snapshots
+	for rows.Next() {
+		var s snapshots
+		var before, after sql.NullString
+		if err := rows.Scan(&s.id, &before, &after); err != nil {
+			rows.Close()
+			return err
+		}
+		b, bok := rebaseMoveSnapshot(before.String, from, to)
+		a, aok := rebaseMoveSnapshot(after.String, from, to)
+		if bok || aok {
+			changed = append(changed, snapshots{s.id, b, a})
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, s := range changed {
+		if _, err := tx.Exec(`UPDATE operations SET before = ?, after = ? WHERE id = ?`, s.before, s.after, s.id); err != nil {
+			return wrapError(err)
+		}
+	}
+	return nil
+}
+
+// rebaseMoveSnapshot rebases the path in a JSON MoveSnapshot from the folder
+// from to to. It reports whether the path was below from.
+func rebaseMoveSnapshot(snapshot string, from string, to string) (string, bool) {
+	var move MoveSnapshot
+	if err := json.Unmarshal([]byte(snapshot), &move); err != nil {
+		return snapshot, false
+	}
+	path, ok := RebasePath(move.Path, from, to)
+	if !ok {
+		return snapshot, false
+	}
+	data, _ := json.Marshal(MoveSnapshot{Path: path})
+	return string(data), true
+}
+
+// RebasePath returns path moved from below the folder from to below to, and
+// whether path was at or below from
+func RebasePath(path string, from string, to string) (string, bool) {
+	if path == from {
+		return to, true
+	}
+	if rest, ok := strings.CutPrefix(path, from+string(filepath.Separator)); ok {
+		return filepath.Join(to, rest), true
+	}
+	return path, false
+}
+
+// rootOfQuery selects the deepest root containing products.path. It takes
+// the path separator as its only parameter.
+const rootOfQuery = `
+	SELECT r.id FROM library_roots r
+	WHERE products.path = r.path OR substr(products.path, 1, length(r.path) + 1) = r.path || ?1
+	ORDER BY length(r.path) DESC LIMIT 1`
+
+// relPathQuery selects products.path relative to its root, with "/" as the
+// separator so it is portable between platforms. It takes the path separator
+// as its only parameter.
+const relPathQuery = `
+	SELECT CASE WHEN products.path = r.path THEN '' ELSE replace(substr(products.path, length(r.path) + 2), ?1, '/') END
+	FROM library_roots r WHERE r.id = products.root_id`
+
+// assignRootTx sets the root and relative path of the product at path
+func assignRootTx(q querier, path string) error {
+	sep := string(filepath.Separator)
+	if _, err := q.Exec(`UPDATE products SET root_id = (`+rootOfQuery+`) WHERE path = ?2`, sep, path); err != nil {
+		return err
+	}
+	_, err := q.Exec(`UPDATE products SET rel_path = (`+relPathQuery+`) WHERE path = ?2`, sep, path)
+	return err
+}
+
+// CountProductsByRoot returns the number of products in each root
+func CountProductsByRoot() (map[string]int, error) {
+	rows, err := DB.Query(`SELECT root_id, COUNT(*) FROM products WHERE root_id IS NOT NULL GROUP BY root_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var id string
+		var n int
+		if err := rows.Scan(&id, &n); err != nil {
+			return nil, err
+		}
+		counts[id] = n
+	}
+	return counts, rows.Err()
+}
@@ -0,0 +1,246 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// SearchDocument is the searchable text of a product
+type SearchDocument struct {
+	Path        string
+	Name        string
+	ShopName    string
+	Tags        string // Space-separated tag paths
+	Description string
+}
+
+// ProductEmbedding is the stored vector of a product
+type ProductEmbedding struct {
+	Path   string
+	Vector []float32
+}
+
+// searchDocumentColumns are the searchable columns of a product p
+const searchDocumentColumns = `
+	p.path, COALESCE(p.name, ''), COALESCE(p.shop_name, ''),
+	COALESCE((SELECT group_concat(tp.path, ' ') FROM product_tags pt JOIN tag_paths tp ON tp.id = pt.tag_id WHERE pt.product_id = p.id), ''),
+	COALESCE(p.description, '')
+`
+
+// searchDocumentsQuery selects one row per product for embeddings
+const searchDocumentsQuery = `SELECT ` + searchDocumentColumns + ` FROM products p`
+
+// indexProductsStatement adds the full-text rows of the products matching
+// the condition that follows it. Rows are keyed by product id.
+const indexProductsStatement = `INSERT INTO products_fts (rowid, path, name, shop, tags, description) SELECT p.id, ` + searchDocumentColumns + ` FROM products p WHERE `
+
+// textIndexTriggers keep products_fts in step with every write to products,
+// product_tags and tags, so searching never has to rebuild the index
+var textIndexTriggers = []string{
+	`CREATE TRIGGER IF NOT EXISTS products_fts_insert AFTER INSERT ON products BEGIN
+		` + indexProductsStatement + `p.id = new.id;
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS products_fts_update AFTER UPDATE OF path, name, shop_name, description ON products BEGIN
+		DELETE FROM products_fts WHERE rowid = old.id;
+		` + indexProductsStatement + `p.id = new.id;
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS products_fts_delete AFTER DELETE ON products BEGIN
+		DELETE FROM products_fts WHERE rowid = old.id;
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS product_tags_fts_insert AFTER INSERT ON product_tags BEGIN
+		DELETE FROM products_fts WHERE rowid = new.product_id;
+		` + indexProductsStatement + `p.id = new.product_id;
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS product_tags_fts_update AFTER UPDATE ON product_tags BEGIN
+		DELETE FROM products_fts WHERE rowid IN (old.product_id, new.product_id);
+		` + indexProductsStatement + `p.id IN (old.product_id, new.product_id);
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS product_tags_fts_delete AFTER DELETE ON product_tags BEGIN
+		DELETE FROM products_fts WHERE rowid = old.product_id;
+		` + indexProductsStatement + `p.id = old.product_id;
+	END`,
+	// Renaming or moving a tag changes the path of every tag below it, so
+	// every tagged product is refreshed; tag edits are rare
+	`CREATE TRIGGER IF NOT EXISTS tags_fts_update AFTER UPDATE OF name, parent_id ON tags BEGIN
+		DELETE FROM products_fts WHERE rowid IN (SELECT product_id FROM product_tags);
+		` + indexProductsStatement + `p.id IN (SELECT product_id FROM product_tags);
+	END`,
+}
+
+// createTextIndexTriggers installs textIndexTriggers and rebuilds the index
+// once when they are new, since rows written before had no triggers
+func createTextIndexTriggers() error {
+	var existing int
+	if err := DB.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'trigger' AND name LIKE '%_fts_%'`).Scan(&existing); err != nil {
+		return err
+	}
+	for _, trigger := range textIndexTriggers {
+		if _, err := DB.Exec(trigger); err != nil {
+			return err
+		}
+	}
+	if existing == len(textIndexTriggers) {
+		return nil
+	}
+	return RefreshTextIndex()
+}
+
+// SetProductDescription stores the product page description used for search
+func SetProductDescription(path string, description string) error {
+	_, err := DB.Exec(`UPDATE products SET description = ? WHERE path = ?`, nullIfEmpty(description), path)
+	return err
+}
+
+// SearchDocuments returns the searchable text of every product, ordered by path
+func SearchDocuments() ([]SearchDocument, error) {
+	rows, err := DB.Query(searchDocumentsQuery + ` ORDER BY p.path`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var docs []SearchDocument
+	for rows.Next() {
+		var d SearchDocument
+		if err := rows.Scan(&d.Path, &d.Name, &d.ShopName, &d.Tags, &d.Description); err != nil {
+			return nil, err
+		}
+		docs = append(docs, d)
+	}
+	return docs, rows.Err()
+}
+
+// RefreshTextIndex rebuilds the full-text index from the products table.
+// Triggers keep the index current, so this is only needed to repair it.
+func RefreshTextIndex() error {
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM products_fts`); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(indexProductsStatement + `1`); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// TextSearch returns the paths of products matching any word of query,
+// best match first. The index uses trigrams, so words shorter than three
+// characters are matched with LIKE instead.
+func TextSearch(query string, limit int) ([]string, error) {
+	var terms, short []string
+	for _, word := range strings.Fields(query) {
+		if len([]rune(word)) >= 3 {
+			terms = append(terms, `"`+strings.ReplaceAll(word, `"`, `""`)+`"`)
+		} else {
+			short = append(short, word)
+		}
+	}
+
+	var rows *sql.Rows
+	var err error
+	switch {
+	case len(terms) > 0:
+		rows, err = DB.Query(`SELECT path FROM products_fts WHERE products_fts MATCH ? ORDER BY rank LIMIT ?`, strings.Join(terms, " OR "), limit)
+	case len(short) > 0:
+		var conditions []string
+		var args []any
+		for _, word := range short {
+			conditions = append(conditions, `(name LIKE ? OR shop LIKE ? OR tags LIKE ? OR description LIKE ?)`)
+			pattern := "%" + word + "%"
+			args = append(args, pattern, pattern, pattern, pattern)
+		}
+		args = append(args, limit)
+		rows, err = DB.Query(`SELECT path FROM products_fts WHERE `+strings.Join(conditions, " OR ")+` LIMIT ?`, args...)
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("text search failed: %w", err)
+	}
+	defer rows.Close()
+
+	var paths []string
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, rows.Err()
+}
+
+// EmbeddingHashes returns the text hash each product was embedded from with model, by path
+func EmbeddingHashes(model string) (map[string]string, error) {
+	rows, err := DB.Query(`SELECT p.path, e.text_hash FROM product_embeddings e JOIN products p ON p.id = e.product_id WHERE e.model = ?`, model)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hashes := make(map[string]string)
+	for rows.Next() {
+		var path, hash string
+		if err := rows.Scan(&path, &hash); err != nil {
+			return nil, err
+		}
+		hashes[path] = hash
+	}
+	return hashes, rows.Err()
+}
+
+// SaveEmbedding stores the vector of a product for model
+func SaveEmbedding(path string, model string, textHash string, vector []float32) error {
+	query := `
+	INSERT OR REPLACE INTO product_embeddings (product_id, model, text_hash, vector)
+	SELECT id, ?, ?, ? FROM products WHERE path = ?
+	`
+	_, err := DB.Exec(query, model, textHash, encodeVector(vector), path)
+	return err
+}
+
+// LoadEmbeddings returns every product vector stored for model
+func LoadEmbeddings(model string) ([]ProductEmbedding, error) {
+	rows, err := DB.Query(`SELECT p.path, e.vector FROM product_embeddings e JOIN products p ON p.id = e.product_id WHERE e.model = ?`, model)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var embeddings []ProductEmbedding
+	for rows.Next() {
+		var e ProductEmbedding
+		var blob []byte
+		if err := rows.Scan(&e.Path, &blob); err != nil {
+			return nil, err
+		}
+		e.Vector = decodeVector(blob)
+		embeddings = append(embeddings, e)
+	}
+	return embeddings, rows.Err()
+}
+
+// encodeVector stores float32s little-endian
+func encodeVector(v []float32) []byte {
+	buf := make([]byte, 4*len(v))
+	for i, f := range v {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(f))
+	}
+	return buf
+}
+
+func decodeVector(buf []byte) []float32 {
+	v := make([]float32, len(buf)/4)
+	for i := range v {
+		v[i] = math.Float32frombits(binary.LittleEndian.Uint32(buf[4*i:]))
+	}
+	return v
+}
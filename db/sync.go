@@ -0,0 +1,171 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+)
+
+// SyncPeer is another install whose catalog was merged from the sync folder
+type SyncPeer struct {
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	ExportedAt time.Time `json:"exportedAt"` // ExportedAt of the catalog last merged
+	SyncedAt   time.Time `json:"syncedAt"`
+}
+
+// Sides of a sync conflict that were kept
+const (
+	KeptLocal  = "local"
+	KeptRemote = "remote"
+)
+
+// SyncConflict is a field that was changed both locally and by a peer since
+// the last sync; the newer change won
+type SyncConflict struct {
+	ID          int64     `json:"id"`
+	Path        string    `json:"path"`
+	Field       string    `json:"field"` // FieldURL, FieldImageURL, FieldShopName or FieldTags
+	LocalValue  string    `json:"localValue"`
+	RemoteValue string    `json:"remoteValue"`
+	Peer        string    `json:"peer"` // Name of the peer
+	Kept        string    `json:"kept"` // KeptLocal or KeptRemote
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// FieldTimes returns when each field of each product was last modified, by
+// product path. Fields not modified since times were first recorded are absent.
+func FieldTimes() (map[string]map[string]time.Time, error) {
+	rows, err := DB.Query(`
+		SELECT p.path, f.field, f.modified_at
+		FROM product_field_times f JOIN products p ON p.id = f.product_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	times := make(map[string]map[string]time.Time)
+	for rows.Next() {
+		var path, field string
+		var modified int64
+		if err := rows.Scan(&path, &field, &modified); err != nil {
+			return nil, err
+		}
+		if times[path] == nil {
+			times[path] = make(map[string]time.Time)
+		}
+		times[path][field] = time.Unix(0, modified)
+	}
+	return times, rows.Err()
+}
+
+// GetSyncPeer returns the state of a peer, or nil if it was never synced
+func GetSyncPeer(id string) (*SyncPeer, error) {
+	var p SyncPeer
+	var name sql.NullString
+	var exported, synced sql.NullInt64
+	err := DB.QueryRow(`SELECT id, name, exported_at, synced_at FROM sync_peers WHERE id = ?`, id).
+		Scan(&p.ID, &name, &exported, &synced)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	p.Name = name.String
+	p.ExportedAt = unixNano(exported)
+	p.SyncedAt = unixNano(synced)
+	return &p, nil
+}
+
+// ListSyncPeers returns every peer synced so far, most recent first
+func ListSyncPeers() ([]SyncPeer, error) {
+	rows, err := DB.Query(`SELECT id, name, exported_at, synced_at FROM sync_peers ORDER BY synced_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	peers := []SyncPeer{}
+	for rows.Next() {
+		var p SyncPeer
+		var name sql.NullString
+		var exported, synced sql.NullInt64
+		if err := rows.Scan(&p.ID, &name, &exported, &synced); err != nil {
+			return nil, err
+		}
+		p.Name = name.String
+		p.ExportedAt = unixNano(exported)
+		p.SyncedAt = unixNano(synced)
+		peers = append(peers, p)
+	}
+	return peers, rows.Err()
+}
+
+// SaveSyncPeer inserts or updates the state of a peer
+func SaveSyncPeer(p SyncPeer) error {
+	query := `
+	INSERT INTO sync_peers (id, name, exported_at, synced_at) VALUES (?, ?, ?, ?)
+	ON CONFLICT(id) DO UPDATE SET
+		name = excluded.name,
+		exported_at = excluded.exported_at,
+		synced_at = excluded.synced_at
+	`
+	_, err := DB.Exec(query, p.ID, p.Name, p.ExportedAt.UnixNano(), p.SyncedAt.UnixNano())
+	return err
+}
+
+// RecordSyncConflicts appends conflicts to the conflict log
+func RecordSyncConflicts(conflicts []SyncConflict) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, c := range conflicts {
+		query := `
+		INSERT INTO sync_conflicts (product_id, path, field, local_value, remote_value, peer, kept)
+		VALUES ((SELECT id FROM products WHERE path = ?1), ?1, ?2, ?3, ?4, ?5, ?6)`
+		if _, err := tx.Exec(query, c.Path, c.Field, c.LocalValue, c.RemoteValue, c.Peer, c.Kept); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ListSyncConflicts returns the newest limit entries of the conflict log
+func ListSyncConflicts(limit int) ([]SyncConflict, error) {
+	rows, err := DB.Query(`
+		SELECT c.id, COALESCE(p.path, c.path), c.field, c.local_value, c.remote_value, c.peer, c.kept, c.created_at
+		FROM sync_conflicts c LEFT JOIN products p ON p.id = c.product_id
+		ORDER BY c.id DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	conflicts := []SyncConflict{}
+	for rows.Next() {
+		var c SyncConflict
+		var local, remote sql.NullString
+		if err := rows.Scan(&c.ID, &c.Path, &c.Field, &local, &remote, &c.Peer, &c.Kept, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		c.LocalValue = local.String
+		c.RemoteValue = remote.String
+		conflicts = append(conflicts, c)
+	}
+	return conflicts, rows.Err()
+}
+
+// ClearSyncConflicts empties the conflict log
+func ClearSyncConflicts() error {
+	_, err := DB.Exec(`DELETE FROM sync_conflicts`)
+	return err
+}
+
+func unixNano(v sql.NullInt64) time.Time {
+	if !v.Valid || v.Int64 == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, v.Int64)
+}
@@ -0,0 +1,659 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrTagNotFound is returned when a tag ID or name doesn't exist
+var ErrTagNotFound = errors.New("tag not found")
+
+// TagInfo describes a tag for the tag manager
+type TagInfo struct {
+	ID         int64    `json:"id"`
+	Name       string   `json:"name"`
+	Path       string   `json:"path"` // Full path including parents, e.g. "衣装/トップス"
+	ParentID   int64    `json:"parentId"`
+	Color      string   `json:"color"`
+	Icon       string   `json:"icon"`
+	Aliases    []string `json:"aliases"`
+	UsageCount int      `json:"usageCount"`
+}
+
+// ListTags returns every tag with its path, aliases and usage count, ordered by path
+func ListTags() ([]TagInfo, error) {
+	query := `
+	SELECT t.id, t.name, tp.path, t.parent_id, t.color, t.icon,
+		(SELECT COUNT(*) FROM product_tags pt WHERE pt.tag_id = t.id)
+	FROM tags t
+	JOIN tag_paths tp ON tp.id = t.id
+	ORDER BY tp.path
+	`
+	rows, err := DB.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tags []TagInfo
+	index := make(map[int64]int)
+	for rows.Next() {
+		var tag TagInfo
+		var parentId sql.NullInt64
+		var color, icon sql.NullString
+		if err := rows.Scan(&tag.ID, &tag.Name, &tag.Path, &parentId, &color, &icon, &tag.UsageCount); err != nil {
+			return nil, err
+		}
+		tag.ParentID = parentId.Int64
+		tag.Color = color.String
+		tag.Icon = icon.String
+		index[tag.ID] = len(tags)
+		tags = append(tags, tag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	aliasRows, err := DB.Query(`SELECT alias, tag_id FROM tag_aliases ORDER BY alias`)
+	if err != nil {
+		return nil, err
+	}
+	defer aliasRows.Close()
+
+	for aliasRows.Next() {
+		var alias string
+		var tagId int64
+		if err := aliasRows.Scan(&alias, &tagId); err != nil {
+			return nil, err
+		}
+		if i, ok := index[tagId]; ok {
+			tags[i].Aliases = append(tags[i].Aliases, alias)
+		}
+	}
+
+	return tags, aliasRows.Err()
+}
+
+// CreateTag creates a tag (and its parents, for paths like "衣装/トップス") and returns its ID.
+// An existing tag or alias with that name is returned instead of creating a new one.
+func CreateTag(name string) (int64, error) {
+	tx, err := DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	tagId, err := resolveTagTx(tx, name, true)
+	if err != nil {
+		return 0, err
+	}
+	return tagId, wrapError(tx.Commit())
+}
+
+// RenameTag changes a tag's name. Products keep the tag since links are by
+// ID, as do its children and aliases. The rename itself is journaled so
+// undo renames the same tag back.
+func RenameTag(tagID int64, newName string) error {
+	newName = strings.TrimSpace(newName)
+	if newName == "" || strings.Contains(newName, "/") {
+		return fmt.Errorf("invalid tag name: %q", newName)
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var oldName, oldPath string
+	err = tx.QueryRow(`SELECT t.name, tp.path FROM tags t JOIN tag_paths tp ON tp.id = t.id WHERE t.id = ?`, tagID).Scan(&oldName, &oldPath)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("%w: %d", ErrTagNotFound, tagID)
+	} else if err != nil {
+		return err
+	}
+	if err := setTagNameTx(tx, tagID, newName); err != nil {
+		return err
+	}
+
+	before, _ := json.Marshal(TagSnapshot{ID: tagID, Name: oldName})
+	after, _ := json.Marshal(TagSnapshot{ID: tagID, Name: newName})
+	err = recordOperationTx(tx, &Operation{
+		BatchID: NewBatchID(),
+		Kind:    OpTagName,
+		Path:    oldPath,
+		Before:  string(before),
+		After:   string(after),
+	})
+	if err != nil {
+		return wrapError(err)
+	}
+	return wrapError(tx.Commit())
+}
+
+// ApplyTagSnapshot renames a tag to the name in snapshot without journaling
+// it, for undo and redo
+func ApplyTagSnapshot(snapshot TagSnapshot) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := setTagNameTx(tx, snapshot.ID, snapshot.Name); err != nil {
+		return err
+	}
+	return wrapError(tx.Commit())
+}
+
+// setTagNameTx renames a tag and stamps the tag field of the products whose
+// tag paths change, so synced catalogs pick up the new paths
+func setTagNameTx(tx *sql.Tx, tagID int64, name string) error {
+	_, err := stampTagChangeTx(tx, []int64{tagID}, func() error {
+		res, err := tx.Exec(`UPDATE tags SET name = ? WHERE id = ?`, name, tagID)
+		if err != nil {
+			return wrapError(err)
+		}
+		return expectRow(res, tagID)
+	})
+	return err
+}
+
+// MergeTags moves every product, alias and child tag from source to target
+// and deletes source. The source name becomes an alias of target so it keeps
+// resolving to the same tag. Children whose name target already uses are
+// merged into target's children. The products' tag changes are journaled
+// as one batch.
+func MergeTags(sourceID int64, targetID int64) error {
+	if sourceID == targetID {
+		return fmt.Errorf("cannot merge a tag into itself")
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = journalTagChangeTx(tx, []int64{sourceID, targetID}, func() error {
+		return mergeTagsTx(tx, sourceID, targetID)
+	})
+	if err != nil {
+		return err
+	}
+	return wrapError(tx.Commit())
+}
+
+func mergeTagsTx(tx *sql.Tx, sourceID int64, targetID int64) error {
+	var sourceName string
+	var sourceParent sql.NullInt64
+	err := tx.QueryRow(`SELECT name, parent_id FROM tags WHERE id = ?`, sourceID).Scan(&sourceName, &sourceParent)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("%w: %d", ErrTagNotFound, sourceID)
+	} else if err != nil {
+		return err
+	}
+	if err := requireTagTx(tx, targetID); err != nil {
+		return err
+	}
+
+	// If target lives below source, lift it to source's parent first. Source
+	// is renamed out of the way since both may have the same name.
+	isDescendant, err := isDescendantTx(tx, targetID, sourceID)
+	if err != nil {
+		return err
+	}
+	if isDescendant {
+		if _, err := tx.Exec(`UPDATE tags SET name = '/' || id WHERE id = ?`, sourceID); err != nil {
+			return wrapError(err)
+		}
+		if _, err := tx.Exec(`UPDATE tags SET parent_id = ? WHERE id = ?`, sourceParent, targetID); err != nil {
+			return wrapError(err)
+		}
+	}
+
+	statements := []struct {
+		query string
+		args  []any
+	}{
+		{`INSERT OR IGNORE INTO product_tags (product_id, tag_id) SELECT product_id, ? FROM product_tags WHERE tag_id = ?`, []any{targetID, sourceID}},
+		{`DELETE FROM product_tags WHERE tag_id = ?`, []any{sourceID}},
+		{`UPDATE tag_aliases SET tag_id = ? WHERE tag_id = ?`, []any{targetID, sourceID}},
+	}
+	for _, st := range statements {
+		if _, err := tx.Exec(st.query, st.args...); err != nil {
+			return wrapError(err)
+		}
+	}
+	if err := reparentChildrenTx(tx, sourceID, targetID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM tags WHERE id = ?`, sourceID); err != nil {
+		return wrapError(err)
+	}
+
+	// Keep the old name resolving, unless a tag still has it
+	var used int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM tags WHERE name = ?`, sourceName).Scan(&used); err != nil {
+		return err
+	}
+	if used == 0 {
+		if _, err := tx.Exec(`INSERT OR REPLACE INTO tag_aliases (alias, tag_id) VALUES (?, ?)`, sourceName, targetID); err != nil {
+			return wrapError(err)
+		}
+	}
+	return nil
+}
+
+// reparentChildrenTx moves the children of tagID below parentID (0 for
+// top-level). A child whose name is already used there is merged into that tag.
+func reparentChildrenTx(tx *sql.Tx, tagID int64, parentID int64) error {
+	rows, err := tx.Query(`SELECT id, name FROM tags WHERE parent_id = ?`, tagID)
+	if err != nil {
+		return err
+	}
+	type child struct {
+		id   int64
+		name string
+	}
+	var children []child
+	for rows.Next() {
+		var c child
+		if err := rows.Scan(&c.id, &c.name); err != nil {
+			rows.Close()
+			return err
+		}
+		children = append(children, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	parent := sql.NullInt64{Int64: parentID, Valid: parentID != 0}
+	for _, c := range children {
+		existing, err := childTagTx(tx, parentID, c.name)
+		if err != nil {
+			return err
+		}
+		if existing != 0 && existing != c.id {
+			if err := mergeTagsTx(tx, c.id, existing); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err := tx.Exec(`UPDATE tags SET parent_id = ? WHERE id = ?`, parent, c.id); err != nil {
+			return wrapError(err)
+		}
+	}
+	return nil
+}
+
+// DeleteTag removes a tag from every product and deletes it. Its children
+// move up to its parent and its aliases are removed. The products' tag
+// changes are journaled as one batch.
+func DeleteTag(tagID int64) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var parent sql.NullInt64
+	err = tx.QueryRow(`SELECT parent_id FROM tags WHERE id = ?`, tagID).Scan(&parent)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("%w: %d", ErrTagNotFound, tagID)
+	} else if err != nil {
+		return err
+	}
+
+	err = journalTagChangeTx(tx, []int64{tagID}, func() error {
+		// Rename the tag out of the way so a child with the same name can take its place
+		if _, err := tx.Exec(`UPDATE tags SET name = '/' || id WHERE id = ?`, tagID); err != nil {
+			return wrapError(err)
+		}
+		if err := reparentChildrenTx(tx, tagID, parent.Int64); err != nil {
+			return err
+		}
+
+		statements := []struct {
+			query string
+			args  []any
+		}{
+			{`DELETE FROM product_tags WHERE tag_id = ?`, []any{tagID}},
+			{`DELETE FROM tag_aliases WHERE tag_id = ?`, []any{tagID}},
+			{`DELETE FROM tags WHERE id = ?`, []any{tagID}},
+		}
+		for _, st := range statements {
+			if _, err := tx.Exec(st.query, st.args...); err != nil {
+				return wrapError(err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return wrapError(tx.Commit())
+}
+
+// SetTagParent moves a tag below another tag; parentID 0 makes it a top-level tag
+func SetTagParent(tagID int64, parentID int64) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := requireTagTx(tx, tagID); err != nil {
+		return err
+	}
+
+	parent := sql.NullInt64{Int64: parentID, Valid: parentID != 0}
+	if parent.Valid {
+		if err := requireTagTx(tx, parentID); err != nil {
+			return err
+		}
+		// Refuse to create a cycle
+		cycle, err := isDescendantTx(tx, parentID, tagID)
+		if err != nil {
+			return err
+		}
+		if cycle || parentID == tagID {
+			return fmt.Errorf("a tag cannot be moved below itself")
+		}
+	}
+
+	err = journalTagChangeTx(tx, []int64{tagID}, func() error {
+		_, err := tx.Exec(`UPDATE tags SET parent_id = ? WHERE id = ?`, parent, tagID)
+		return wrapError(err)
+	})
+	if err != nil {
+		return err
+	}
+	return wrapError(tx.Commit())
+}
+
+// journalTagChangeTx runs change, which edits the given tags, and journals
+// the resulting tag list of every product using one of them or a tag below
+// them as a metadata operation, all in one batch so a single undo restores
+// them
+func journalTagChangeTx(tx *sql.Tx, tagIDs []int64, change func() error) error {
+	ops, err := stampTagChangeTx(tx, tagIDs, change)
+	if err != nil {
+		return err
+	}
+	batchID := NewBatchID()
+	for i := range ops {
+		ops[i].BatchID = batchID
+		if err := recordOperationTx(tx, &ops[i]); err != nil {
+			return wrapError(err)
+		}
+	}
+	return nil
+}
+
+// stampTagChangeTx runs change, which edits the given tags, and stamps the
+// tag field of every product using one of them or a tag below them whose
+// tag list changed. It returns those changes as metadata operations without
+// a batch.
+func stampTagChangeTx(tx *sql.Tx, tagIDs []int64, change func() error) ([]Operation, error) {
+	args := make([]any, len(tagIDs))
+	for i, id := range tagIDs {
+		args[i] = id
+	}
+	query := `
+	WITH RECURSIVE subtree(id) AS (
+		SELECT id FROM tags WHERE id IN (?` + strings.Repeat(", ?", len(tagIDs)-1) + `)
+		UNION
+		SELECT t.id FROM tags t JOIN subtree ON t.parent_id = subtree.id
+	)
+	SELECT DISTINCT p.path FROM products p
+	JOIN product_tags pt ON pt.product_id = p.id
+	WHERE pt.tag_id IN (SELECT id FROM subtree)
+	ORDER BY p.path`
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	before := make([]string, len(paths))
+	ids := make([]int64, len(paths))
+	for i, path := range paths {
+		if ids[i], before[i], err = snapshotProductTx(tx, path); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := change(); err != nil {
+		return nil, err
+	}
+
+	var ops []Operation
+	for i, path := range paths {
+		_, after, err := snapshotProductTx(tx, path)
+		if err != nil {
+			return nil, err
+		}
+		if after == before[i] {
+			continue
+		}
+		if err := stampFieldsTx(tx, ids[i], before[i], after, nil); err != nil {
+			return nil, err
+		}
+		ops = append(ops, Operation{
+			Kind:      OpMetadata,
+			ProductID: ids[i],
+			Path:      path,
+			Before:    before[i],
+			After:     after,
+		})
+	}
+	return ops, nil
+}
+
+// SetTagStyle sets the display color (e.g. "#f97316") and icon of a tag
+func SetTagStyle(tagID int64, color string, icon string) error {
+	res, err := DB.Exec(`UPDATE tags SET color = ?, icon = ? WHERE id = ?`, nullIfEmpty(color), nullIfEmpty(icon), tagID)
+	if err != nil {
+		return wrapError(err)
+	}
+	return expectRow(res, tagID)
+}
+
+// AddTagAlias makes alias resolve to the tag, e.g. "outfit" -> "衣装"
+func AddTagAlias(tagID int64, alias string) error {
+	alias = strings.TrimSpace(alias)
+	if alias == "" {
+		return fmt.Errorf("alias is empty")
+	}
+
+	var count int
+	if err := DB.QueryRow(`SELECT COUNT(*) FROM tags WHERE name = ?`, alias).Scan(&count); err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("%w: a tag named %q already exists; merge the tags instead", ErrConstraint, alias)
+	}
+
+	if err := requireTagTx(DB, tagID); err != nil {
+		return err
+	}
+	_, err := DB.Exec(`INSERT INTO tag_aliases (alias, tag_id) VALUES (?, ?)`, alias, tagID)
+	return wrapError(err)
+}
+
+// RemoveTagAlias deletes an alias
+func RemoveTagAlias(alias string) error {
+	_, err := DB.Exec(`DELETE FROM tag_aliases WHERE alias = ?`, strings.TrimSpace(alias))
+	return err
+}
+
+// resolveTagTx finds the tag for a name, alias or "parent/child" path. The
+// first segment is a top-level tag or an alias, and each further segment is
+// looked up among the children of the previous one, so the same name can be
+// used under different parents. A single name that is neither also matches
+// a nested tag if exactly one has that name.
+//
+// A leading "/" anchors the path at the top level and skips aliases and
+// nested matches, e.g. "/avatar/マヌカ" for tags written by code.
+//
+// With create set, missing segments are created below their parent;
+// otherwise 0 is returned for an unknown name. Existing tags are never moved.
+func resolveTagTx(q querier, name string, create bool) (int64, error) {
+	name = strings.TrimSpace(name)
+	absolute := strings.HasPrefix(name, "/")
+	if name == "" {
+		return 0, nil
+	}
+	if strings.Contains(name, "/") && !absolute {
+		if tagId, err := aliasTagTx(q, name); err != nil || tagId != 0 {
+			return tagId, err
+		}
+	}
+
+	var segments []string
+	for _, segment := range strings.Split(name, "/") {
+		if segment = strings.TrimSpace(segment); segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+
+	var parentId int64
+	for i, segment := range segments {
+		tagId, err := childTagTx(q, parentId, segment)
+		if err != nil {
+			return 0, err
+		}
+		if tagId == 0 && i == 0 && !absolute {
+			if tagId, err = aliasTagTx(q, segment); err != nil {
+				return 0, err
+			}
+		}
+		if tagId == 0 && len(segments) == 1 && !absolute {
+			if tagId, err = uniqueTagTx(q, segment); err != nil {
+				return 0, err
+			}
+		}
+		if tagId == 0 {
+			if !create {
+				return 0, nil
+			}
+			parent := sql.NullInt64{Int64: parentId, Valid: parentId != 0}
+			res, err := q.Exec(`INSERT INTO tags (name, parent_id) VALUES (?, ?)`, segment, parent)
+			if err != nil {
+				return 0, wrapError(err)
+			}
+			if tagId, err = res.LastInsertId(); err != nil {
+				return 0, err
+			}
+		}
+		parentId = tagId
+	}
+
+	return parentId, nil
+}
+
+// childTagTx returns the tag named name below parentID (0 for top-level), or 0
+func childTagTx(q querier, parentID int64, name string) (int64, error) {
+	var tagId int64
+	err := q.QueryRow(`SELECT id FROM tags WHERE COALESCE(parent_id, 0) = ? AND name = ?`, parentID, name).Scan(&tagId)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return tagId, err
+}
+
+// aliasTagTx returns the tag the alias points to, or 0
+func aliasTagTx(q querier, alias string) (int64, error) {
+	var tagId int64
+	err := q.QueryRow(`SELECT tag_id FROM tag_aliases WHERE alias = ?`, alias).Scan(&tagId)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return tagId, err
+}
+
+// uniqueTagTx returns the only tag named name at any level, or 0 if there is
+// none. A name used by several tags must be given as a full path.
+func uniqueTagTx(q querier, name string) (int64, error) {
+	rows, err := q.Query(`SELECT id FROM tags WHERE name = ? LIMIT 2`, name)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(ids) > 1 {
+		return 0, fmt.Errorf("tag name %q is used under several parents; give its full path", name)
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	return ids[0], nil
+}
+
+// isDescendantTx reports whether tagID is somewhere below ancestorID
+func isDescendantTx(q querier, tagID int64, ancestorID int64) (bool, error) {
+	query := `
+	WITH RECURSIVE up(id) AS (
+		SELECT parent_id FROM tags WHERE id = ?
+		UNION
+		SELECT t.parent_id FROM tags t JOIN up ON t.id = up.id
+	)
+	SELECT COUNT(*) FROM up WHERE id = ?
+	`
+	var count int
+	if err := q.QueryRow(query, tagID, ancestorID).Scan(&count); err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func requireTagTx(q querier, tagID int64) error {
+	var count int
+	if err := q.QueryRow(`SELECT COUNT(*) FROM tags WHERE id = ?`, tagID).Scan(&count); err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("%w: %d", ErrTagNotFound, tagID)
+	}
+	return nil
+}
+
+func expectRow(res sql.Result, tagID int64) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: %d", ErrTagNotFound, tagID)
+	}
+	return nil
+}
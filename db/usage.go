@@ -0,0 +1,73 @@
+package db
+
+import "fmt"
+
+// AIUsage is a single recorded AI request
+type AIUsage struct {
+	Task         string // e.g. "booth-lookup", "tag-suggest"
+	Model        string // Provider and model, e.g. "gemini/gemini-2.5-flash"
+	InputTokens  int
+	OutputTokens int
+	LatencyMs    int64
+	Success      bool
+	Error        string
+}
+
+// AIUsageSummary is the usage of one task on one (local) day
+type AIUsageSummary struct {
+	Day          string `json:"day"` // YYYY-MM-DD
+	Task         string `json:"task"`
+	Requests     int    `json:"requests"`
+	Failures     int    `json:"failures"`
+	InputTokens  int    `json:"inputTokens"`
+	OutputTokens int    `json:"outputTokens"`
+	AvgLatencyMs int64  `json:"avgLatencyMs"`
+}
+
+// RecordAIUsage stores one AI request
+func RecordAIUsage(u AIUsage) error {
+	query := `INSERT INTO ai_usage (task, model, input_tokens, output_tokens, latency_ms, success, error) VALUES (?, ?, ?, ?, ?, ?, ?)`
+	_, err := DB.Exec(query, u.Task, u.Model, u.InputTokens, u.OutputTokens, u.LatencyMs, u.Success, nullIfEmpty(u.Error))
+	return err
+}
+
+// AIUsageToday returns the number of requests and tokens (input + output) used today, local time
+func AIUsageToday() (requests int, tokens int, err error) {
+	query := `
+	SELECT COUNT(*), COALESCE(SUM(input_tokens + output_tokens), 0)
+	FROM ai_usage
+	WHERE date(created_at, 'localtime') = date('now', 'localtime')
+	`
+	err = DB.QueryRow(query).Scan(&requests, &tokens)
+	return requests, tokens, err
+}
+
+// AIUsageByDay returns usage per day and task for the last days days (local time), newest first.
+// days is at least 1, which returns today only.
+func AIUsageByDay(days int) ([]AIUsageSummary, error) {
+	days = max(days, 1)
+	query := `
+	SELECT date(created_at, 'localtime') AS day, task, COUNT(*),
+		SUM(CASE WHEN success THEN 0 ELSE 1 END),
+		SUM(input_tokens), SUM(output_tokens), CAST(AVG(latency_ms) AS INTEGER)
+	FROM ai_usage
+	WHERE date(created_at, 'localtime') > date('now', 'localtime', ?)
+	GROUP BY day, task
+	ORDER BY day DESC, task
+	`
+	rows, err := DB.Query(query, fmt.Sprintf("-%d days", days))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var summaries []AIUsageSummary
+	for rows.Next() {
+		var s AIUsageSummary
+		if err := rows.Scan(&s.Day, &s.Task, &s.Requests, &s.Failures, &s.InputTokens, &s.OutputTokens, &s.AvgLatencyMs); err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, s)
+	}
+	return summaries, rows.Err()
+}
@@ -0,0 +1,20 @@
+package db
+
+import "testing"
+
+func TestAIUsageByDayReturnsTodayForNonPositiveDays(t *testing.T) {
+	openTestDB(t)
+	if err := RecordAIUsage(AIUsage{Task: "booth-lookup", Model: "gemini/test", InputTokens: 10, OutputTokens: 5, Success: true}); err != nil {
+		t.Fatalf("RecordAIUsage: %v", err)
+	}
+
+	for _, days := range []int{-3, 0, 1, 7} {
+		summaries, err := AIUsageByDay(days)
+		if err != nil {
+			t.Fatalf("AIUsageByDay(%d): %v", days, err)
+		}
+		if len(summaries) != 1 || summaries[0].Requests != 1 || summaries[0].OutputTokens != 5 {
+			t.Errorf("AIUsageByDay(%d) = %+v, want today's request", days, summaries)
+		}
+	}
+}
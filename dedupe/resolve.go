@@ -0,0 +1,120 @@
+package dedupe
+
+import (
+	"aslm/db"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Action is how a duplicate group is resolved
+type Action string
+
+const (
+	// ActionDelete deletes every copy except the kept one
+	ActionDelete Action = "delete"
+	// ActionHardlink replaces every copy except the kept one with a hardlink to it
+	ActionHardlink Action = "hardlink"
+	// ActionKeep leaves all copies in place and hides the group from future reports
+	ActionKeep Action = "keep"
+)
+
+// Resolve resolves a duplicate group. keep must be one of paths.
+// Every file is re-hashed from disk, ignoring the hash cache, and the whole
+// group is checked before anything is touched, so nothing is deleted or
+// replaced if any file changed since the scan. Paths are compared after
+// cleaning them and listed paths are handled once. A path that is the kept
+// file under another name (a symlink, a hardlink, different case) is
+// refused, and each file is compared with the kept one again right before
+// it is removed or linked.
+func Resolve(hash string, keep string, paths []string, action Action) error {
+	if action == ActionKeep {
+		return db.IgnoreDuplicateGroup(hash)
+	}
+	if action != ActionDelete && action != ActionHardlink {
+		return fmt.Errorf("unknown action: %s", action)
+	}
+
+	keep = filepath.Clean(keep)
+	var others []string
+	seen := make(map[string]bool)
+	for _, p := range paths {
+		p = filepath.Clean(p)
+		if seen[p] {
+			continue
+		}
+		seen[p] = true
+		if p != keep {
+			others = append(others, p)
+		}
+	}
+	if !seen[keep] {
+		return fmt.Errorf("kept file is not part of the group: %s", keep)
+	}
+
+	keepInfo, err := os.Stat(keep)
+	if err != nil {
+		return err
+	}
+	for _, p := range append([]string{keep}, others...) {
+		if p != keep {
+			if err := checkNotKept(p, keepInfo); err != nil {
+				return err
+			}
+		}
+		h, err := sha256File(p, -1)
+		if err != nil {
+			return err
+		}
+		if h != hash {
+			return fmt.Errorf("file has changed since the scan: %s", p)
+		}
+	}
+
+	for _, p := range others {
+		if err := checkNotKept(p, keepInfo); err != nil {
+			return err
+		}
+
+		switch action {
+		case ActionDelete:
+			if err := os.Remove(p); err != nil {
+				return err
+			}
+			if err := db.DeleteFileHash(p); err != nil {
+				return err
+			}
+		case ActionHardlink:
+			if err := replaceWithHardlink(keep, p); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkNotKept fails if path is the kept file under another name
+func checkNotKept(path string, keep os.FileInfo) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if os.SameFile(info, keep) {
+		return fmt.Errorf("%s is the kept file under another name", path)
+	}
+	return nil
+}
+
+// replaceWithHardlink atomically replaces target with a hardlink to source
+func replaceWithHardlink(source string, target string) error {
+	tmp := filepath.Join(filepath.Dir(target), fmt.Sprintf(".%s.aslm-link", filepath.Base(target)))
+	if err := os.Link(source, tmp); err != nil {
+		return fmt.Errorf("failed to create hardlink for %s: %w", target, err)
+	}
+	if err := os.Rename(tmp, target); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package dedupe
+
+import (
+	"aslm/config"
+	"aslm/db"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const content = "same bytes"
+
+func openTestDB(t *testing.T) {
+	t.Helper()
+	config.SetDataDir(t.TempDir())
+	if err := db.InitDB(); err != nil {
+		t.Fatalf("InitDB: %v", err)
+	}
+	t.Cleanup(func() { db.DB.Close() })
+}
+
+// duplicates creates files with the same content and returns their paths
+// and hash
+func duplicates(t *testing.T, names ...string) ([]string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	var paths []string
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+	sum := sha256.Sum256([]byte(content))
+	return paths, hex.EncodeToString(sum[:])
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func sameFile(t *testing.T, a string, b string) bool {
+	t.Helper()
+	ai, err := os.Stat(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bi, err := os.Stat(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return os.SameFile(ai, bi)
+}
+
+func TestResolveDeleteWithAliasedAndRepeatedPaths(t *testing.T) {
+	openTestDB(t)
+	paths, hash := duplicates(t, "a.unitypackage", "b.unitypackage")
+	keep, other := paths[0], paths[1]
+	dir := filepath.Dir(keep)
+
+	// The kept file written differently, and both files listed twice
+	aliasKeep := dir + string(filepath.Separator) + "." + string(filepath.Separator) + filepath.Base(keep)
+	group := []string{keep, other, aliasKeep, other + string(filepath.Separator)}
+	if err := Resolve(hash, aliasKeep, group, ActionDelete); err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if !exists(keep) {
+		t.Error("the kept file was deleted")
+	}
+	if exists(other) {
+		t.Error("the duplicate was not deleted")
+	}
+}
+
+func TestResolveHardlinkWithRepeatedPaths(t *testing.T) {
+	openTestDB(t)
+	paths, hash := duplicates(t, "a.unitypackage", "b.unitypackage", "c.unitypackage")
+	keep := paths[0]
+
+	sep := string(filepath.Separator)
+	group := append([]string{}, paths...)
+	group = append(group, paths[1], filepath.Dir(paths[2])+sep+sep+filepath.Base(paths[2]))
+	if err := Resolve(hash, keep, group, ActionHardlink); err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	for _, p := range paths[1:] {
+		if !sameFile(t, keep, p) {
+			t.Errorf("%s is not linked to the kept file", p)
+		}
+	}
+}
+
+func TestResolveRefusesAnotherNameForTheKeptFile(t *testing.T) {
+	links := map[string]func(string, string) error{
+		"hardlink": os.Link,
+		"symlink":  os.Symlink,
+	}
+	for name, link := range links {
+		for _, action := range []Action{ActionDelete, ActionHardlink} {
+			t.Run(name+"/"+string(action), func(t *testing.T) {
+				openTestDB(t)
+				paths, hash := duplicates(t, "a.unitypackage", "b.unitypackage")
+				keep, other := paths[0], paths[1]
+				alias := filepath.Join(filepath.Dir(keep), "alias.unitypackage")
+				if err := link(keep, alias); err != nil {
+					t.Skipf("can't create %s: %v", name, err)
+				}
+
+				if err := Resolve(hash, keep, []string{keep, other, alias}, action); err == nil {
+					t.Fatal("Resolve accepted another name for the kept file")
+				}
+				if !exists(keep) || !exists(alias) || !exists(other) {
+					t.Error("a file was removed")
+				}
+				if sameFile(t, keep, other) {
+					t.Error("a file was linked")
+				}
+			})
+		}
+	}
+}
+
+func TestResolveRefusesChangedFile(t *testing.T) {
+	openTestDB(t)
+	paths, hash := duplicates(t, "a.unitypackage", "b.unitypackage", "c.unitypackage")
+	if err := os.WriteFile(paths[2], []byte("edited"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Resolve(hash, paths[0], paths, ActionDelete); err == nil {
+		t.Fatal("Resolve accepted a changed file")
+	}
+	for _, p := range paths {
+		if !exists(p) {
+			t.Errorf("%s was deleted", p)
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package dedupe
+
+import (
+	"aslm/db"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// partialSize is the number of leading bytes hashed for the partial hash
+const partialSize = 64 * 1024
+
+// Options controls which files are considered by Scan
+type Options struct {
+	Extensions []string // e.g. ".zip", ".unitypackage"; empty means all files
+	MinSize    int64    // files smaller than this are ignored
+}
+
+// Group is a set of files with identical content
+type Group struct {
+	Hash        string   `json:"hash"`
+	Size        int64    `json:"size"`
+	Paths       []string `json:"paths"`
+	WastedBytes int64    `json:"wastedBytes"`
+}
+
+// Report is the result of a duplicate scan
+type Report struct {
+	Groups       []Group `json:"groups"`
+	FilesScanned int     `json:"filesScanned"`
+	WastedBytes  int64   `json:"wastedBytes"`
+}
+
+type candidate struct {
+	path string
+	info fs.FileInfo
+}
+
+// Scan walks the given roots and reports groups of files with identical content.
+// Files are first grouped by size, then by a hash of their first 64KiB, and only
+// the remaining candidates are fully hashed. Hashes are cached in the database
+// and reused as long as the file's size and modification time are unchanged.
+func Scan(roots []string, opts Options) (*Report, error) {
+	report := &Report{}
+	bySize := make(map[int64][]candidate)
+
+	for _, root := range roots {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				// Skip unreadable entries instead of aborting the whole scan
+				return nil
+			}
+			if d.IsDir() || !d.Type().IsRegular() {
+				return nil
+			}
+			if !matchExtension(path, opts.Extensions) {
+				return nil
+			}
+
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			if info.Size() == 0 || info.Size() < opts.MinSize {
+				return nil
+			}
+
+			report.FilesScanned++
+			bySize[info.Size()] = append(bySize[info.Size()], candidate{path: path, info: info})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for size, files := range bySize {
+		if len(files) < 2 {
+			continue
+		}
+
+		// Narrow down by partial hash
+		byPartial := make(map[string][]candidate)
+		for _, c := range files {
+			h, err := hashFile(c.path, c.info, false)
+			if err != nil {
+				continue
+			}
+			byPartial[h.PartialHash] = append(byPartial[h.PartialHash], c)
+		}
+
+		for _, partialGroup := range byPartial {
+			if len(partialGroup) < 2 {
+				continue
+			}
+
+			// Confirm with full hash
+			byFull := make(map[string][]candidate)
+			for _, c := range partialGroup {
+				h, err := hashFile(c.path, c.info, true)
+				if err != nil {
+					continue
+				}
+				byFull[h.FullHash] = append(byFull[h.FullHash], c)
+			}
+
+			for hash, group := range byFull {
+				group = distinctFiles(group)
+				if len(group) < 2 {
+					continue
+				}
+
+				ignored, err := db.IsDuplicateGroupIgnored(hash)
+				if err != nil {
+					return nil, err
+				}
+				if ignored {
+					continue
+				}
+
+				paths := make([]string, 0, len(group))
+				for _, c := range group {
+					paths = append(paths, c.path)
+				}
+				sort.Strings(paths)
+
+				wasted := size * int64(len(paths)-1)
+				report.Groups = append(report.Groups, Group{
+					Hash:        hash,
+					Size:        size,
+					Paths:       paths,
+					WastedBytes: wasted,
+				})
+				report.WastedBytes += wasted
+			}
+		}
+	}
+
+	// Largest waste first
+	sort.Slice(report.Groups, func(i, j int) bool {
+		if report.Groups[i].WastedBytes != report.Groups[j].WastedBytes {
+			return report.Groups[i].WastedBytes > report.Groups[j].WastedBytes
+		}
+		return report.Groups[i].Hash < report.Groups[j].Hash
+	})
+
+	return report, nil
+}
+
+// FileHash returns the full SHA-256 of a file, using the database cache when valid
+func FileHash(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	h, err := hashFile(path, info, true)
+	if err != nil {
+		return "", err
+	}
+	return h.FullHash, nil
+}
+
+// hashFile computes (or loads from cache) the partial and optionally full hash of a file
+func hashFile(path string, info fs.FileInfo, full bool) (*db.FileHash, error) {
+	cached, err := db.GetFileHash(path)
+	if err != nil {
+		return nil, err
+	}
+
+	modTime := info.ModTime().UnixNano()
+	if cached != nil && cached.Size == info.Size() && cached.ModTime == modTime {
+		if !full || cached.FullHash != "" {
+			return cached, nil
+		}
+	} else {
+		cached = &db.FileHash{Path: path, Size: info.Size(), ModTime: modTime}
+	}
+
+	if cached.PartialHash == "" {
+		partial, err := sha256File(path, partialSize)
+		if err != nil {
+			return nil, err
+		}
+		cached.PartialHash = partial
+		// Small files are fully covered by the partial hash
+		if info.Size() <= partialSize {
+			cached.FullHash = partial
+		}
+	}
+
+	if full && cached.FullHash == "" {
+		fullHash, err := sha256File(path, -1)
+		if err != nil {
+			return nil, err
+		}
+		cached.FullHash = fullHash
+	}
+
+	if err := db.SaveFileHash(cached); err != nil {
+		return nil, err
+	}
+	return cached, nil
+}
+
+// sha256File hashes the first limit bytes of a file, or the whole file if limit < 0
+func sha256File(path string, limit int64) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if limit >= 0 {
+		r = io.LimitReader(f, limit)
+	}
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, r); err != nil {
+		return "", fmt.Errorf("failed to hash %s: %w", path, err)
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// distinctFiles drops entries that are hardlinks to a file already in the list
+func distinctFiles(files []candidate) []candidate {
+	var result []candidate
+	for _, c := range files {
+		seen := false
+		for _, r := range result {
+			if os.SameFile(c.info, r.info) {
+				seen = true
+				break
+			}
+		}
+		if !seen {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+func matchExtension(path string, extensions []string) bool {
+	if len(extensions) == 0 {
+		return true
+	}
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, e := range extensions {
+		if strings.ToLower(e) == ext {
+			return true
+		}
+	}
+	return false
+}
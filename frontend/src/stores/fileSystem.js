@@ -1,6 +1,6 @@
 import { defineStore } from 'pinia';
 import { ref } from 'vue';
-import { ListFiles, GetProductByPath } from '../../wailsjs/go/main/App';
+import { ListFiles, GetProductByPath, ListRoots } from '../../wailsjs/go/main/App';
 
 export const useFileSystemStore = defineStore('fileSystem', () => {
   const currentPath = ref('D:/VRChatAssetPack');
@@ -97,6 +97,22 @@ export const useFileSystemStore = defineStore('fileSystem', () => {
   // ホームディレクトリの設定
   const homePath = ref('D:/VRChatAssetPack');
 
+  // ライブラリルート (SSD / HDD / NAS など)
+  const roots = ref([]);
+
+  async function loadRoots() {
+    try {
+      roots.value = (await ListRoots()) || [];
+      // 新規ダウンロード先と同じく、最初の有効なルートをホームにする
+      const home = roots.value.find(r => r.state === 'online') || roots.value.find(r => r.root.enabled);
+      if (home) {
+        homePath.value = home.root.path;
+      }
+    } catch (error) {
+      console.error('Failed to load library roots:', error);
+    }
+  }
+
   function setHomePath(path) {
     homePath.value = path;
     // 必要であればここで永続化処理などを呼び出す
@@ -112,6 +128,8 @@ export const useFileSystemStore = defineStore('fileSystem', () => {
     changeDirectory,
     homePath,
     setHomePath,
+    roots,
+    loadRoots,
     historyStack,
     historyIndex,
     goBack,
@@ -2,23 +2,161 @@
 // This file is automatically generated. DO NOT EDIT
 import {main} from '../models';
 import {db} from '../models';
+import {backup} from '../models';
+import {organize} from '../models';
+import {dedupe} from '../models';
+import {catalog} from '../models';
+import {archive} from '../models';
+import {config} from '../models';
+import {search} from '../models';
+import {ai} from '../models';
+
+export function AddTagAlias(arg1:number,arg2:string):Promise<void>;
+
+export function ApplyTagSuggestions(arg1:string,arg2:main.TagSelection):Promise<void>;
+
+export function ApproveInboxItem(arg1:number,arg2:string,arg3:string,arg4:string,arg5:string):Promise<db.InboxItem>;
 
 export function AutoFetchBoothInfo(arg1:string):Promise<main.BoothInfo>;
 
+export function BulkEditProducts(arg1:Array<string>,arg2:db.ProductPatch):Promise<Array<db.BulkResult>>;
+
+export function BulkEditSavedQuery(arg1:string,arg2:db.ProductPatch):Promise<Array<db.BulkResult>>;
+
+export function CheckDatabase():Promise<db.IntegrityReport>;
+
+export function ClearSyncConflicts():Promise<void>;
+
+export function CreateBackup():Promise<backup.Info>;
+
+export function CreateTag(arg1:string):Promise<number>;
+
+export function DeleteSavedQuery(arg1:string):Promise<void>;
+
+export function DeleteTag(arg1:number):Promise<void>;
+
+export function ExecuteReorganize(arg1:organize.Plan):Promise<organize.Journal>;
+
+export function ExportCatalog(arg1:string,arg2:string):Promise<number>;
+
+export function ExtractArchive(arg1:string):Promise<string>;
+
 export function FetchBoothImageFromURL(arg1:string):Promise<string>;
 
 export function FetchBoothInfoWithGemini(arg1:string):Promise<main.BoothInfo>;
 
-export function GetGeminiApiKey():Promise<string>;
+export function FindDuplicates():Promise<dedupe.Report>;
+
+export function FindProducts(arg1:db.ProductQuery):Promise<Array<db.ProductInfo>>;
+
+export function GetAISettings():Promise<main.AISettings>;
+
+export function GetAIUsage(arg1:number):Promise<main.AIUsageReport>;
+
+export function GetAPIServerStatus():Promise<main.APIServerStatus>;
+
+export function GetAPIToken():Promise<string>;
+
+export function GetBackupSettings():Promise<main.BackupSettings>;
+
+export function GetDatabaseReport():Promise<db.IntegrityReport>;
+
+export function GetGeminiApiKeyStatus():Promise<main.SecretStatus>;
+
+export function GetInboxSettings():Promise<main.InboxSettings>;
 
 export function GetParentProduct(arg1:string):Promise<db.ProductInfo>;
 
 export function GetProductByPath(arg1:string):Promise<db.ProductInfo>;
 
+export function GetSyncStatus():Promise<main.SyncStatus>;
+
 export function Greet(arg1:string):Promise<string>;
 
+export function History(arg1:string):Promise<Array<db.Operation>>;
+
+export function ImportCatalog(arg1:string,arg2:string,arg3:boolean):Promise<catalog.ImportResult>;
+
+export function InspectArchive(arg1:string):Promise<archive.Listing>;
+
+export function ListBackups():Promise<Array<backup.Info>>;
+
 export function ListFiles(arg1:string):Promise<Array<main.FileItem>>;
 
+export function ListInboxItems(arg1:string):Promise<Array<db.InboxItem>>;
+
+export function ListReorganizeJournals():Promise<Array<organize.Journal>>;
+
+export function ListRoots():Promise<Array<main.RootStatus>>;
+
+export function ListSavedQueries():Promise<Array<db.SavedQuery>>;
+
+export function ListSyncConflicts():Promise<Array<db.SyncConflict>>;
+
+export function ListTags():Promise<Array<db.TagInfo>>;
+
+export function MergeTags(arg1:number,arg2:number):Promise<void>;
+
+export function PatchProduct(arg1:string,arg2:db.ProductPatch):Promise<void>;
+
+export function PlanReorganize(arg1:string):Promise<organize.Plan>;
+
+export function ReadArchiveEntry(arg1:string,arg2:string):Promise<string>;
+
+export function RebuildSearchIndex():Promise<number>;
+
+export function Redo():Promise<Array<db.Operation>>;
+
+export function RegenerateAPIToken():Promise<string>;
+
+export function RejectInboxItem(arg1:number):Promise<void>;
+
+export function RemoveTagAlias(arg1:string):Promise<void>;
+
+export function RenameTag(arg1:number,arg2:string):Promise<void>;
+
+export function ResolveDuplicates(arg1:string,arg2:string,arg3:Array<string>,arg4:string):Promise<void>;
+
+export function RestoreBackup(arg1:string):Promise<db.IntegrityReport>;
+
+export function SaveAIApiKey(arg1:string):Promise<void>;
+
+export function SaveAISettings(arg1:main.AISettings):Promise<void>;
+
+export function SaveBackupSettings(arg1:main.BackupSettings):Promise<void>;
+
 export function SaveGeminiApiKey(arg1:string):Promise<void>;
 
+export function SaveInboxSettings(arg1:main.InboxSettings):Promise<void>;
+
+export function SaveQuery(arg1:string,arg2:db.ProductQuery):Promise<void>;
+
+export function SaveRoots(arg1:Array<config.Root>):Promise<void>;
+
+export function SaveSyncSettings(arg1:main.SyncSettings):Promise<void>;
+
+export function ScanInbox():Promise<void>;
+
+export function SearchProducts(arg1:string,arg2:number):Promise<Array<search.Hit>>;
+
+export function SetAIBudget(arg1:number,arg2:number):Promise<void>;
+
+export function SetAPIServer(arg1:boolean,arg2:number):Promise<main.APIServerStatus>;
+
+export function SetTagParent(arg1:number,arg2:number):Promise<void>;
+
+export function SetTagStyle(arg1:number,arg2:string,arg3:string):Promise<void>;
+
+export function SuggestTags(arg1:string):Promise<ai.TagSuggestions>;
+
+export function SuggestTagsBulk(arg1:Array<string>):Promise<Array<main.TagSuggestionResult>>;
+
+export function SyncNow():Promise<catalog.SyncResult>;
+
+export function Undo():Promise<Array<db.Operation>>;
+
+export function UndoReorganize(arg1:string):Promise<void>;
+
 export function UpdateProduct(arg1:string,arg2:string,arg3:string,arg4:string,arg5:Array<string>):Promise<void>;
+
+export function ValidateConfig():Promise<Array<config.FieldError>>;
@@ -2,10 +2,66 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export function AddTagAlias(arg1, arg2) {
+  return window['go']['main']['App']['AddTagAlias'](arg1, arg2);
+}
+
+export function ApplyTagSuggestions(arg1, arg2) {
+  return window['go']['main']['App']['ApplyTagSuggestions'](arg1, arg2);
+}
+
+export function ApproveInboxItem(arg1, arg2, arg3, arg4, arg5) {
+  return window['go']['main']['App']['ApproveInboxItem'](arg1, arg2, arg3, arg4, arg5);
+}
+
 export function AutoFetchBoothInfo(arg1) {
   return window['go']['main']['App']['AutoFetchBoothInfo'](arg1);
 }
 
+export function BulkEditProducts(arg1, arg2) {
+  return window['go']['main']['App']['BulkEditProducts'](arg1, arg2);
+}
+
+export function BulkEditSavedQuery(arg1, arg2) {
+  return window['go']['main']['App']['BulkEditSavedQuery'](arg1, arg2);
+}
+
+export function CheckDatabase() {
+  return window['go']['main']['App']['CheckDatabase']();
+}
+
+export function ClearSyncConflicts() {
+  return window['go']['main']['App']['ClearSyncConflicts']();
+}
+
+export function CreateBackup() {
+  return window['go']['main']['App']['CreateBackup']();
+}
+
+export function CreateTag(arg1) {
+  return window['go']['main']['App']['CreateTag'](arg1);
+}
+
+export function DeleteSavedQuery(arg1) {
+  return window['go']['main']['App']['DeleteSavedQuery'](arg1);
+}
+
+export function DeleteTag(arg1) {
+  return window['go']['main']['App']['DeleteTag'](arg1);
+}
+
+export function ExecuteReorganize(arg1) {
+  return window['go']['main']['App']['ExecuteReorganize'](arg1);
+}
+
+export function ExportCatalog(arg1, arg2) {
+  return window['go']['main']['App']['ExportCatalog'](arg1, arg2);
+}
+
+export function ExtractArchive(arg1) {
+  return window['go']['main']['App']['ExtractArchive'](arg1);
+}
+
 export function FetchBoothImageFromURL(arg1) {
   return window['go']['main']['App']['FetchBoothImageFromURL'](arg1);
 }
@@ -14,8 +70,44 @@ export function FetchBoothInfoWithGemini(arg1) {
   return window['go']['main']['App']['FetchBoothInfoWithGemini'](arg1);
 }
 
-export function GetGeminiApiKey() {
-  return window['go']['main']['App']['GetGeminiApiKey']();
+export function FindDuplicates() {
+  return window['go']['main']['App']['FindDuplicates']();
+}
+
+export function FindProducts(arg1) {
+  return window['go']['main']['App']['FindProducts'](arg1);
+}
+
+export function GetAISettings() {
+  return window['go']['main']['App']['GetAISettings']();
+}
+
+export function GetAIUsage(arg1) {
+  return window['go']['main']['App']['GetAIUsage'](arg1);
+}
+
+export function GetAPIServerStatus() {
+  return window['go']['main']['App']['GetAPIServerStatus']();
+}
+
+export function GetAPIToken() {
+  return window['go']['main']['App']['GetAPIToken']();
+}
+
+export function GetBackupSettings() {
+  return window['go']['main']['App']['GetBackupSettings']();
+}
+
+export function GetDatabaseReport() {
+  return window['go']['main']['App']['GetDatabaseReport']();
+}
+
+export function GetGeminiApiKeyStatus() {
+  return window['go']['main']['App']['GetGeminiApiKeyStatus']();
+}
+
+export function GetInboxSettings() {
+  return window['go']['main']['App']['GetInboxSettings']();
 }
 
 export function GetParentProduct(arg1) {
@@ -26,18 +118,186 @@ export function GetProductByPath(arg1) {
   return window['go']['main']['App']['GetProductByPath'](arg1);
 }
 
+export function GetSyncStatus() {
+  return window['go']['main']['App']['GetSyncStatus']();
+}
+
 export function Greet(arg1) {
   return window['go']['main']['App']['Greet'](arg1);
 }
 
+export function History(arg1) {
+  return window['go']['main']['App']['History'](arg1);
+}
+
+export function ImportCatalog(arg1, arg2, arg3) {
+  return window['go']['main']['App']['ImportCatalog'](arg1, arg2, arg3);
+}
+
+export function InspectArchive(arg1) {
+  return window['go']['main']['App']['InspectArchive'](arg1);
+}
+
+export function ListBackups() {
+  return window['go']['main']['App']['ListBackups']();
+}
+
 export function ListFiles(arg1) {
   return window['go']['main']['App']['ListFiles'](arg1);
 }
 
+export function ListInboxItems(arg1) {
+  return window['go']['main']['App']['ListInboxItems'](arg1);
+}
+
+export function ListReorganizeJournals() {
+  return window['go']['main']['App']['ListReorganizeJournals']();
+}
+
+export function ListRoots() {
+  return window['go']['main']['App']['ListRoots']();
+}
+
+export function ListSavedQueries() {
+  return window['go']['main']['App']['ListSavedQueries']();
+}
+
+export function ListSyncConflicts() {
+  return window['go']['main']['App']['ListSyncConflicts']();
+}
+
+export function ListTags() {
+  return window['go']['main']['App']['ListTags']();
+}
+
+export function MergeTags(arg1, arg2) {
+  return window['go']['main']['App']['MergeTags'](arg1, arg2);
+}
+
+export function PatchProduct(arg1, arg2) {
+  return window['go']['main']['App']['PatchProduct'](arg1, arg2);
+}
+
+export function PlanReorganize(arg1) {
+  return window['go']['main']['App']['PlanReorganize'](arg1);
+}
+
+export function ReadArchiveEntry(arg1, arg2) {
+  return window['go']['main']['App']['ReadArchiveEntry'](arg1, arg2);
+}
+
+export function RebuildSearchIndex() {
+  return window['go']['main']['App']['RebuildSearchIndex']();
+}
+
+export function Redo() {
+  return window['go']['main']['App']['Redo']();
+}
+
+export function RegenerateAPIToken() {
+  return window['go']['main']['App']['RegenerateAPIToken']();
+}
+
+export function RejectInboxItem(arg1) {
+  return window['go']['main']['App']['RejectInboxItem'](arg1);
+}
+
+export function RemoveTagAlias(arg1) {
+  return window['go']['main']['App']['RemoveTagAlias'](arg1);
+}
+
+export function RenameTag(arg1, arg2) {
+  return window['go']['main']['App']['RenameTag'](arg1, arg2);
+}
+
+export function ResolveDuplicates(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['ResolveDuplicates'](arg1, arg2, arg3, arg4);
+}
+
+export function RestoreBackup(arg1) {
+  return window['go']['main']['App']['RestoreBackup'](arg1);
+}
+
+export function SaveAIApiKey(arg1) {
+  return window['go']['main']['App']['SaveAIApiKey'](arg1);
+}
+
+export function SaveAISettings(arg1) {
+  return window['go']['main']['App']['SaveAISettings'](arg1);
+}
+
+export function SaveBackupSettings(arg1) {
+  return window['go']['main']['App']['SaveBackupSettings'](arg1);
+}
+
 export function SaveGeminiApiKey(arg1) {
   return window['go']['main']['App']['SaveGeminiApiKey'](arg1);
 }
 
+export function SaveInboxSettings(arg1) {
+  return window['go']['main']['App']['SaveInboxSettings'](arg1);
+}
+
+export function SaveQuery(arg1, arg2) {
+  return window['go']['main']['App']['SaveQuery'](arg1, arg2);
+}
+
+export function SaveRoots(arg1) {
+  return window['go']['main']['App']['SaveRoots'](arg1);
+}
+
+export function SaveSyncSettings(arg1) {
+  return window['go']['main']['App']['SaveSyncSettings'](arg1);
+}
+
+export function ScanInbox() {
+  return window['go']['main']['App']['ScanInbox']();
+}
+
+export function SearchProducts(arg1, arg2) {
+  return window['go']['main']['App']['SearchProducts'](arg1, arg2);
+}
+
+export function SetAIBudget(arg1, arg2) {
+  return window['go']['main']['App']['SetAIBudget'](arg1, arg2);
+}
+
+export function SetAPIServer(arg1, arg2) {
+  return window['go']['main']['App']['SetAPIServer'](arg1, arg2);
+}
+
+export function SetTagParent(arg1, arg2) {
+  return window['go']['main']['App']['SetTagParent'](arg1, arg2);
+}
+
+export function SetTagStyle(arg1, arg2, arg3) {
+  return window['go']['main']['App']['SetTagStyle'](arg1, arg2, arg3);
+}
+
+export function SuggestTags(arg1) {
+  return window['go']['main']['App']['SuggestTags'](arg1);
+}
+
+export function SuggestTagsBulk(arg1) {
+  return window['go']['main']['App']['SuggestTagsBulk'](arg1);
+}
+
+export function SyncNow() {
+  return window['go']['main']['App']['SyncNow']();
+}
+
+export function Undo() {
+  return window['go']['main']['App']['Undo']();
+}
+
+export function UndoReorganize(arg1) {
+  return window['go']['main']['App']['UndoReorganize'](arg1);
+}
+
 export function UpdateProduct(arg1, arg2, arg3, arg4, arg5) {
   return window['go']['main']['App']['UpdateProduct'](arg1, arg2, arg3, arg4, arg5);
 }
+
+export function ValidateConfig() {
+  return window['go']['main']['App']['ValidateConfig']();
+}
@@ -1,5 +1,532 @@
+export namespace ai {
+	
+	export class SuggestedTag {
+	    name: string;
+	    isNew: boolean;
+	    reason: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new SuggestedTag(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.isNew = source["isNew"];
+	        this.reason = source["reason"];
+	    }
+	}
+	export class TagSuggestions {
+	    tags: SuggestedTag[];
+	    category: string;
+	    avatars: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new TagSuggestions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.tags = this.convertValues(source["tags"], SuggestedTag);
+	        this.category = source["category"];
+	        this.avatars = source["avatars"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace archive {
+	
+	export class Entry {
+	    name: string;
+	    size: number;
+	    compressedSize: number;
+	    isDir: boolean;
+	    // Go type: time
+	    modified: any;
+	    kind: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Entry(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.size = source["size"];
+	        this.compressedSize = source["compressedSize"];
+	        this.isDir = source["isDir"];
+	        this.modified = this.convertValues(source["modified"], null);
+	        this.kind = source["kind"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Listing {
+	    path: string;
+	    format: string;
+	    entries: Entry[];
+	    unityPackages: string[];
+	    readmes: string[];
+	    nestedArchives: string[];
+	    fileCount: number;
+	    totalSize: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Listing(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.format = source["format"];
+	        this.entries = this.convertValues(source["entries"], Entry);
+	        this.unityPackages = source["unityPackages"];
+	        this.readmes = source["readmes"];
+	        this.nestedArchives = source["nestedArchives"];
+	        this.fileCount = source["fileCount"];
+	        this.totalSize = source["totalSize"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace backup {
+	
+	export class Info {
+	    name: string;
+	    path: string;
+	    size: number;
+	    // Go type: time
+	    createdAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new Info(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.path = source["path"];
+	        this.size = source["size"];
+	        this.createdAt = this.convertValues(source["createdAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace catalog {
+	
+	export class ImportChange {
+	    path: string;
+	    matchedBy: string;
+	    fields: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new ImportChange(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.matchedBy = source["matchedBy"];
+	        this.fields = source["fields"];
+	    }
+	}
+	export class ImportResult {
+	    changes: ImportChange[];
+	    unchanged: number;
+	    missing?: string[];
+	    ambiguous?: string[];
+	    failed?: db.BulkResult[];
+	
+	    static createFrom(source: any = {}) {
+	        return new ImportResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.changes = this.convertValues(source["changes"], ImportChange);
+	        this.unchanged = source["unchanged"];
+	        this.missing = source["missing"];
+	        this.ambiguous = source["ambiguous"];
+	        this.failed = this.convertValues(source["failed"], db.BulkResult);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Peer {
+	    id: string;
+	    name: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Peer(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	    }
+	}
+	export class SyncResult {
+	    peers: string[];
+	    unchanged: number;
+	    changes: ImportChange[];
+	    conflicts: db.SyncConflict[];
+	    failed?: db.BulkResult[];
+	    errors?: string[];
+	    exported: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new SyncResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.peers = source["peers"];
+	        this.unchanged = source["unchanged"];
+	        this.changes = this.convertValues(source["changes"], ImportChange);
+	        this.conflicts = this.convertValues(source["conflicts"], db.SyncConflict);
+	        this.failed = this.convertValues(source["failed"], db.BulkResult);
+	        this.errors = source["errors"];
+	        this.exported = source["exported"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace config {
+	
+	export class FieldError {
+	    field: string;
+	    message: string;
+	    severity: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new FieldError(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.field = source["field"];
+	        this.message = source["message"];
+	        this.severity = source["severity"];
+	    }
+	}
+	export class ScanRules {
+	    include?: string[];
+	    exclude?: string[];
+	    maxDepth?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ScanRules(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.include = source["include"];
+	        this.exclude = source["exclude"];
+	        this.maxDepth = source["maxDepth"];
+	    }
+	}
+	export class Root {
+	    id: string;
+	    label: string;
+	    path: string;
+	    enabled: boolean;
+	    scan: ScanRules;
+	
+	    static createFrom(source: any = {}) {
+	        return new Root(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.label = source["label"];
+	        this.path = source["path"];
+	        this.enabled = source["enabled"];
+	        this.scan = this.convertValues(source["scan"], ScanRules);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
 export namespace db {
 	
+	export class AIUsageSummary {
+	    day: string;
+	    task: string;
+	    requests: number;
+	    failures: number;
+	    inputTokens: number;
+	    outputTokens: number;
+	    avgLatencyMs: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new AIUsageSummary(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.day = source["day"];
+	        this.task = source["task"];
+	        this.requests = source["requests"];
+	        this.failures = source["failures"];
+	        this.inputTokens = source["inputTokens"];
+	        this.outputTokens = source["outputTokens"];
+	        this.avgLatencyMs = source["avgLatencyMs"];
+	    }
+	}
+	export class BulkResult {
+	    path: string;
+	    error?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new BulkResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.error = source["error"];
+	    }
+	}
+	export class InboxItem {
+	    id: number;
+	    path: string;
+	    title: string;
+	    shopName: string;
+	    url: string;
+	    imageUrl: string;
+	    destination: string;
+	    status: string;
+	    reason: string;
+	    // Go type: time
+	    createdAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new InboxItem(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.path = source["path"];
+	        this.title = source["title"];
+	        this.shopName = source["shopName"];
+	        this.url = source["url"];
+	        this.imageUrl = source["imageUrl"];
+	        this.destination = source["destination"];
+	        this.status = source["status"];
+	        this.reason = source["reason"];
+	        this.createdAt = this.convertValues(source["createdAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class IntegrityReport {
+	    ok: boolean;
+	    problems: string[];
+	    foreignKeys: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new IntegrityReport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.ok = source["ok"];
+	        this.problems = source["problems"];
+	        this.foreignKeys = source["foreignKeys"];
+	    }
+	}
+	export class Operation {
+	    id: number;
+	    batchId: string;
+	    kind: string;
+	    productId: number;
+	    path: string;
+	    before: string;
+	    after: string;
+	    undone: boolean;
+	    // Go type: time
+	    createdAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new Operation(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.batchId = source["batchId"];
+	        this.kind = source["kind"];
+	        this.productId = source["productId"];
+	        this.path = source["path"];
+	        this.before = source["before"];
+	        this.after = source["after"];
+	        this.undone = source["undone"];
+	        this.createdAt = this.convertValues(source["createdAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 	export class ProductInfo {
 	    Path: string;
 	    Name: string;
@@ -7,6 +534,9 @@ export namespace db {
 	    ImageUrl: string;
 	    ShopName: string;
 	    Tags: string[];
+	    RootID: string;
+	    RelPath: string;
+	    Offline: boolean;
 	
 	    static createFrom(source: any = {}) {
 	        return new ProductInfo(source);
@@ -20,17 +550,385 @@ export namespace db {
 	        this.ImageUrl = source["ImageUrl"];
 	        this.ShopName = source["ShopName"];
 	        this.Tags = source["Tags"];
+	        this.RootID = source["RootID"];
+	        this.RelPath = source["RelPath"];
+	        this.Offline = source["Offline"];
+	    }
+	}
+	export class ProductPatch {
+	    url?: string;
+	    imageUrl?: string;
+	    shopName?: string;
+	    tags?: string[];
+	    addTags?: string[];
+	    removeTags?: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new ProductPatch(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.url = source["url"];
+	        this.imageUrl = source["imageUrl"];
+	        this.shopName = source["shopName"];
+	        this.tags = source["tags"];
+	        this.addTags = source["addTags"];
+	        this.removeTags = source["removeTags"];
+	    }
+	}
+	export class ProductQuery {
+	    text?: string;
+	    tags?: string[];
+	    shop?: string;
+	    untagged?: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new ProductQuery(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.text = source["text"];
+	        this.tags = source["tags"];
+	        this.shop = source["shop"];
+	        this.untagged = source["untagged"];
+	    }
+	}
+	export class SavedQuery {
+	    name: string;
+	    query: ProductQuery;
+	
+	    static createFrom(source: any = {}) {
+	        return new SavedQuery(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.query = this.convertValues(source["query"], ProductQuery);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class SyncConflict {
+	    id: number;
+	    path: string;
+	    field: string;
+	    localValue: string;
+	    remoteValue: string;
+	    peer: string;
+	    kept: string;
+	    // Go type: time
+	    createdAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new SyncConflict(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.path = source["path"];
+	        this.field = source["field"];
+	        this.localValue = source["localValue"];
+	        this.remoteValue = source["remoteValue"];
+	        this.peer = source["peer"];
+	        this.kept = source["kept"];
+	        this.createdAt = this.convertValues(source["createdAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class SyncPeer {
+	    id: string;
+	    name: string;
+	    // Go type: time
+	    exportedAt: any;
+	    // Go type: time
+	    syncedAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new SyncPeer(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.exportedAt = this.convertValues(source["exportedAt"], null);
+	        this.syncedAt = this.convertValues(source["syncedAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class TagInfo {
+	    id: number;
+	    name: string;
+	    path: string;
+	    parentId: number;
+	    color: string;
+	    icon: string;
+	    aliases: string[];
+	    usageCount: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new TagInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.path = source["path"];
+	        this.parentId = source["parentId"];
+	        this.color = source["color"];
+	        this.icon = source["icon"];
+	        this.aliases = source["aliases"];
+	        this.usageCount = source["usageCount"];
 	    }
 	}
 
 }
 
+export namespace dedupe {
+	
+	export class Group {
+	    hash: string;
+	    size: number;
+	    paths: string[];
+	    wastedBytes: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Group(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.hash = source["hash"];
+	        this.size = source["size"];
+	        this.paths = source["paths"];
+	        this.wastedBytes = source["wastedBytes"];
+	    }
+	}
+	export class Report {
+	    groups: Group[];
+	    filesScanned: number;
+	    wastedBytes: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Report(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.groups = this.convertValues(source["groups"], Group);
+	        this.filesScanned = source["filesScanned"];
+	        this.wastedBytes = source["wastedBytes"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
 export namespace main {
 	
+	export class SecretStatus {
+	    configured: boolean;
+	    masked: string;
+	    storage: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new SecretStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.configured = source["configured"];
+	        this.masked = source["masked"];
+	        this.storage = source["storage"];
+	    }
+	}
+	export class AISettings {
+	    provider: string;
+	    model: string;
+	    endpoint: string;
+	    apiKey: SecretStatus;
+	
+	    static createFrom(source: any = {}) {
+	        return new AISettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.provider = source["provider"];
+	        this.model = source["model"];
+	        this.endpoint = source["endpoint"];
+	        this.apiKey = this.convertValues(source["apiKey"], SecretStatus);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class AIUsageReport {
+	    days: db.AIUsageSummary[];
+	    todayRequests: number;
+	    todayTokens: number;
+	    dailyTokenLimit: number;
+	    dailyRequestLimit: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new AIUsageReport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.days = this.convertValues(source["days"], db.AIUsageSummary);
+	        this.todayRequests = source["todayRequests"];
+	        this.todayTokens = source["todayTokens"];
+	        this.dailyTokenLimit = source["dailyTokenLimit"];
+	        this.dailyRequestLimit = source["dailyRequestLimit"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class APIServerStatus {
+	    enabled: boolean;
+	    running: boolean;
+	    address: string;
+	    port: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new APIServerStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.running = source["running"];
+	        this.address = source["address"];
+	        this.port = source["port"];
+	    }
+	}
+	export class BackupSettings {
+	    enabled: boolean;
+	    intervalHours: number;
+	    keep: number;
+	    dir: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new BackupSettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.intervalHours = source["intervalHours"];
+	        this.keep = source["keep"];
+	        this.dir = source["dir"];
+	    }
+	}
 	export class BoothInfo {
 	    productUrl: string;
 	    imageUrl: string;
 	    shopName: string;
+	    confidence?: number;
 	
 	    static createFrom(source: any = {}) {
 	        return new BoothInfo(source);
@@ -41,6 +939,7 @@ export namespace main {
 	        this.productUrl = source["productUrl"];
 	        this.imageUrl = source["imageUrl"];
 	        this.shopName = source["shopName"];
+	        this.confidence = source["confidence"];
 	    }
 	}
 	export class FileItem {
@@ -69,6 +968,301 @@ export namespace main {
 	        this.tags = source["tags"];
 	    }
 	}
+	export class InboxSettings {
+	    path: string;
+	    template: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new InboxSettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.template = source["template"];
+	    }
+	}
+	export class RootStatus {
+	    root: config.Root;
+	    state: string;
+	    products: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new RootStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.root = this.convertValues(source["root"], config.Root);
+	        this.state = source["state"];
+	        this.products = source["products"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class SyncSettings {
+	    folder: string;
+	    name: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new SyncSettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.folder = source["folder"];
+	        this.name = source["name"];
+	    }
+	}
+	export class SyncStatus {
+	    folder: string;
+	    name: string;
+	    self: catalog.Peer;
+	    peers: db.SyncPeer[];
+	
+	    static createFrom(source: any = {}) {
+	        return new SyncStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.folder = source["folder"];
+	        this.name = source["name"];
+	        this.self = this.convertValues(source["self"], catalog.Peer);
+	        this.peers = this.convertValues(source["peers"], db.SyncPeer);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class TagSelection {
+	    tags: string[];
+	    category: string;
+	    avatars: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new TagSelection(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.tags = source["tags"];
+	        this.category = source["category"];
+	        this.avatars = source["avatars"];
+	    }
+	}
+	export class TagSuggestionResult {
+	    path: string;
+	    suggestions?: ai.TagSuggestions;
+	    error?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new TagSuggestionResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.suggestions = this.convertValues(source["suggestions"], ai.TagSuggestions);
+	        this.error = source["error"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace organize {
+	
+	export class Move {
+	    product: string;
+	    from: string;
+	    to: string;
+	    conflict?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Move(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.product = source["product"];
+	        this.from = source["from"];
+	        this.to = source["to"];
+	        this.conflict = source["conflict"];
+	    }
+	}
+	export class Journal {
+	    id: string;
+	    root: string;
+	    template: string;
+	    // Go type: time
+	    createdAt: any;
+	    moves: Move[];
+	    undone: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new Journal(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.root = source["root"];
+	        this.template = source["template"];
+	        this.createdAt = this.convertValues(source["createdAt"], null);
+	        this.moves = this.convertValues(source["moves"], Move);
+	        this.undone = source["undone"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class Plan {
+	    root: string;
+	    template: string;
+	    moves: Move[];
+	    unchanged: number;
+	    conflicts: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Plan(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.root = source["root"];
+	        this.template = source["template"];
+	        this.moves = this.convertValues(source["moves"], Move);
+	        this.unchanged = source["unchanged"];
+	        this.conflicts = source["conflicts"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace search {
+	
+	export class Hit {
+	    product: db.ProductInfo;
+	    score: number;
+	    textRank: number;
+	    vectorRank: number;
+	    similarity: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Hit(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.product = this.convertValues(source["product"], db.ProductInfo);
+	        this.score = source["score"];
+	        this.textRank = source["textRank"];
+	        this.vectorRank = source["vectorRank"];
+	        this.similarity = source["similarity"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 
 }
 
@@ -0,0 +1,131 @@
+package fsutil
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// invalidNameChars are characters that cannot appear in a file name on Windows
+const invalidNameChars = `<>:"/\|?*`
+
+// SanitizeName makes s safe to use as a single file or folder name on all platforms
+func SanitizeName(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r < 0x20 || strings.ContainsRune(invalidNameChars, r) {
+			b.WriteRune('_')
+			continue
+		}
+		b.WriteRune(r)
+	}
+
+	// Windows does not allow names ending in a dot or space
+	name := strings.TrimRight(strings.TrimSpace(b.String()), ". ")
+	if name == "" {
+		name = "_"
+	}
+	return name
+}
+
+// UniquePath returns path if nothing exists there, otherwise the first free
+// variant of the form "name (2).ext", "name (3).ext", ...
+func UniquePath(path string) string {
+	ext := filepath.Ext(path)
+	return uniquePath(strings.TrimSuffix(path, ext), ext)
+}
+
+// UniqueDir is like UniquePath but never treats part of the name as an
+// extension, so "Outfit v1.2" becomes "Outfit v1.2 (2)"
+func UniqueDir(path string) string {
+	return uniquePath(path, "")
+}
+
+func uniquePath(base string, ext string) string {
+	if _, err := os.Lstat(base + ext); os.IsNotExist(err) {
+		return base + ext
+	}
+	for i := 2; ; i++ {
+		candidate := fmt.Sprintf("%s (%d)%s", base, i, ext)
+		if _, err := os.Lstat(candidate); os.IsNotExist(err) {
+			return candidate
+		}
+	}
+}
+
+// IsWithin reports whether path is inside (or equal to) dir
+func IsWithin(dir string, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
+
+// Move moves a file or folder, falling back to copy and delete when src and dst
+// are on different volumes (e.g. ~/Downloads on C: and the library on D:)
+func Move(src string, dst string) error {
+	if _, err := os.Lstat(dst); err == nil {
+		return fmt.Errorf("destination already exists: %s", dst)
+	}
+
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	} else if _, statErr := os.Lstat(src); statErr != nil {
+		return err
+	}
+
+	if err := copyTree(src, dst); err != nil {
+		os.RemoveAll(dst)
+		return err
+	}
+	return os.RemoveAll(src)
+}
+
+func copyTree(src string, dst string) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+
+	if !info.IsDir() {
+		return copyFile(src, dst, info)
+	}
+
+	if err := os.MkdirAll(dst, info.Mode().Perm()); err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := copyTree(filepath.Join(src, entry.Name()), filepath.Join(dst, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func copyFile(src string, dst string, info os.FileInfo) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_EXCL|os.O_WRONLY, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Chtimes(dst, info.ModTime(), info.ModTime())
+}
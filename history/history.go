@@ -0,0 +1,115 @@
+package history
+
+import (
+	"aslm/db"
+	"aslm/fsutil"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Undo reverts the most recent batch of journaled operations and returns them.
+// It returns nil if there is nothing to undo.
+func Undo() ([]db.Operation, error) {
+	ops, err := db.LatestBatch(false)
+	if err != nil || len(ops) == 0 {
+		return nil, err
+	}
+
+	// Revert in reverse order
+	for i := len(ops) - 1; i >= 0; i-- {
+		if err := apply(ops[i], ops[i].Before, ops[i].After, true); err != nil {
+			return nil, fmt.Errorf("failed to undo operation %d: %w", ops[i].ID, err)
+		}
+	}
+
+	if err := db.SetBatchUndone(ops[0].BatchID, true); err != nil {
+		return nil, err
+	}
+	return ops, nil
+}
+
+// Redo re-applies the most recently undone batch and returns it.
+// It returns nil if there is nothing to redo.
+func Redo() ([]db.Operation, error) {
+	ops, err := db.LatestBatch(true)
+	if err != nil || len(ops) == 0 {
+		return nil, err
+	}
+
+	for _, op := range ops {
+		if err := apply(op, op.After, op.Before, false); err != nil {
+			return nil, fmt.Errorf("failed to redo operation %d: %w", op.ID, err)
+		}
+	}
+
+	if err := db.SetBatchUndone(ops[0].BatchID, false); err != nil {
+		return nil, err
+	}
+	return ops, nil
+}
+
+// apply puts an operation into the target state, coming from the current state
+func apply(op db.Operation, target string, current string, undo bool) error {
+	switch op.Kind {
+	case db.OpMetadata:
+		var snapshot db.ProductSnapshot
+		if err := json.Unmarshal([]byte(target), &snapshot); err != nil {
+			return err
+		}
+		path, err := db.GetProductPath(op.ProductID)
+		if err != nil {
+			return err
+		}
+		return db.ApplyProductSnapshot(path, snapshot)
+
+	case db.OpTagName:
+		var snapshot db.TagSnapshot
+		if err := json.Unmarshal([]byte(target), &snapshot); err != nil {
+			return err
+		}
+		return db.ApplyTagSnapshot(snapshot)
+
+	case db.OpMove, db.OpFileMove:
+		var from, to db.MoveSnapshot
+		if err := json.Unmarshal([]byte(current), &from); err != nil {
+			return err
+		}
+		if err := json.Unmarshal([]byte(target), &to); err != nil {
+			return err
+		}
+		return move(op, from.Path, to.Path, undo)
+	}
+
+	return fmt.Errorf("unknown operation kind: %s", op.Kind)
+}
+
+// move relocates a folder or file. A move that was already reverted by other
+// means (e.g. a reorganize undone from its file journal) is skipped. A file
+// moved back into the inbox is queued for review again, so the inbox
+// watcher doesn't file it a second time.
+func move(op db.Operation, from string, to string, undo bool) error {
+	if _, err := os.Lstat(from); os.IsNotExist(err) {
+		if _, err := os.Lstat(to); err == nil {
+			return nil
+		}
+		return fmt.Errorf("%s no longer exists", from)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(to), 0755); err != nil {
+		return err
+	}
+	if err := fsutil.Move(from, to); err != nil {
+		return err
+	}
+
+	switch {
+	case op.Kind == db.OpMove:
+		return db.MoveProductPaths([]db.PathMove{{From: from, To: to}})
+	case undo:
+		return db.RelocateInboxItem(from, to, db.InboxPending, "filing undone")
+	default:
+		return db.RelocateInboxItem(from, to, db.InboxFiled, "filing redone")
+	}
+}
@@ -0,0 +1,109 @@
+package history
+
+import (
+	"aslm/config"
+	"aslm/db"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func openTestDB(t *testing.T) {
+	t.Helper()
+	config.SetDataDir(t.TempDir())
+	if err := db.InitDB(); err != nil {
+		t.Fatalf("InitDB: %v", err)
+	}
+	t.Cleanup(func() { db.DB.Close() })
+}
+
+// tagsByID returns every tag keyed by ID
+func tagsByID(t *testing.T) map[int64]db.TagInfo {
+	t.Helper()
+	tags, err := db.ListTags()
+	if err != nil {
+		t.Fatalf("ListTags: %v", err)
+	}
+	byID := make(map[int64]db.TagInfo)
+	for _, tag := range tags {
+		byID[tag.ID] = tag
+	}
+	return byID
+}
+
+func productTags(t *testing.T, path string) []string {
+	t.Helper()
+	info, err := db.GetProductInfo(path)
+	if err != nil || info == nil {
+		t.Fatalf("GetProductInfo(%s) = %v, %v", path, info, err)
+	}
+	return info.Tags
+}
+
+func TestUndoRenameTagWithChildren(t *testing.T) {
+	openTestDB(t)
+
+	dir := t.TempDir()
+	hat := filepath.Join(dir, "Hat")
+	outfit := filepath.Join(dir, "Outfit")
+	for _, path := range []string{hat, outfit} {
+		if err := db.RegisterProduct(path, filepath.Base(path)); err != nil {
+			t.Fatalf("RegisterProduct: %v", err)
+		}
+	}
+	if err := db.UpdateProduct(hat, "", "", "", []string{"clothes/hat"}); err != nil {
+		t.Fatalf("UpdateProduct: %v", err)
+	}
+	if err := db.UpdateProduct(outfit, "", "", "", []string{"clothes"}); err != nil {
+		t.Fatalf("UpdateProduct: %v", err)
+	}
+	clothes, err := db.CreateTag("clothes")
+	if err != nil {
+		t.Fatalf("CreateTag: %v", err)
+	}
+	if err := db.AddTagAlias(clothes, "outfits"); err != nil {
+		t.Fatalf("AddTagAlias: %v", err)
+	}
+	before := tagsByID(t)
+
+	if err := db.RenameTag(clothes, "wear"); err != nil {
+		t.Fatalf("RenameTag: %v", err)
+	}
+	if got := productTags(t, hat); !slices.Equal(got, []string{"wear/hat"}) {
+		t.Fatalf("tags after rename = %v", got)
+	}
+
+	ops, err := Undo()
+	if err != nil {
+		t.Fatalf("Undo: %v", err)
+	}
+	if len(ops) != 1 || ops[0].Kind != db.OpTagName {
+		t.Fatalf("undone = %+v, want one tag rename", ops)
+	}
+	after := tagsByID(t)
+	if len(after) != len(before) {
+		t.Fatalf("undo left %d tags, want %d: %v", len(after), len(before), after)
+	}
+	for id, tag := range before {
+		got := after[id]
+		if got.Name != tag.Name || got.Path != tag.Path || got.ParentID != tag.ParentID || got.UsageCount != tag.UsageCount || !slices.Equal(got.Aliases, tag.Aliases) {
+			t.Errorf("tag %d after undo = %+v, want %+v", id, got, tag)
+		}
+	}
+	if got := productTags(t, hat); !slices.Equal(got, []string{"clothes/hat"}) {
+		t.Errorf("hat tags after undo = %v", got)
+	}
+	if got := productTags(t, outfit); !slices.Equal(got, []string{"clothes"}) {
+		t.Errorf("outfit tags after undo = %v", got)
+	}
+
+	if _, err := Redo(); err != nil {
+		t.Fatalf("Redo: %v", err)
+	}
+	if got := tagsByID(t)[clothes].Name; got != "wear" {
+		t.Errorf("name after redo = %q", got)
+	}
+	if got := productTags(t, hat); !slices.Equal(got, []string{"wear/hat"}) {
+		t.Errorf("hat tags after redo = %v", got)
+	}
+}
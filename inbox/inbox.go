@@ -0,0 +1,372 @@
+package inbox
+
+import (
+	"aslm/booth"
+	"aslm/db"
+	"aslm/fsutil"
+	"aslm/organize"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// DefaultInterval is how often the inbox folder is polled
+const DefaultInterval = 10 * time.Second
+
+// Extensions are the download types picked up from the inbox
+var Extensions = []string{".zip", ".unitypackage"}
+
+// Match is the result of identifying a download
+type Match struct {
+	Title     string
+	Version   string
+	ShopName  string
+	Url       string
+	ImageUrl  string
+	Tags      []string
+	Product   string // Existing product folder the download belongs to, if any
+	Confident bool
+	Reason    string
+}
+
+// Watcher polls an inbox folder and files new downloads into the library
+type Watcher struct {
+	Dir      string
+	Home     string
+	Template string
+	Interval time.Duration
+	OnEvent  func(event string, item db.InboxItem) // "filed", "queued" or "failed"
+
+	mu    sync.Mutex
+	sizes map[string]int64
+}
+
+// Run polls the inbox until ctx is cancelled
+func (w *Watcher) Run(ctx context.Context) {
+	interval := w.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.Scan(); err != nil {
+			log.Printf("Inbox scan failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Scan looks at the inbox once and processes every download whose size has
+// stopped changing since the previous scan (i.e. the browser finished writing it)
+func (w *Watcher) Scan() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	entries, err := os.ReadDir(w.Dir)
+	if err != nil {
+		return err
+	}
+
+	previous := w.sizes
+	w.sizes = make(map[string]int64)
+
+	for _, entry := range entries {
+		if entry.IsDir() || !isDownload(entry.Name()) {
+			continue
+		}
+
+		path := filepath.Join(w.Dir, entry.Name())
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		w.sizes[path] = info.Size()
+
+		if size, seen := previous[path]; !seen || size != info.Size() {
+			// New or still growing; look again on the next scan
+			continue
+		}
+
+		known, err := db.IsInboxPathKnown(path)
+		if err != nil {
+			return err
+		}
+		if known {
+			continue
+		}
+
+		w.process(path)
+	}
+
+	return nil
+}
+
+// process identifies a download and files it, or queues it for review
+func (w *Watcher) process(path string) {
+	match := Identify(path)
+
+	item := db.InboxItem{
+		Path:     path,
+		Title:    match.Title,
+		ShopName: match.ShopName,
+		Url:      match.Url,
+		ImageUrl: match.ImageUrl,
+		Reason:   match.Reason,
+	}
+
+	dest, err := w.destination(match)
+	if err != nil {
+		item.Status = db.InboxFailed
+		item.Reason = err.Error()
+		w.save(&item, "failed")
+		return
+	}
+	item.Destination = dest
+
+	if !match.Confident {
+		item.Status = db.InboxPending
+		w.save(&item, "queued")
+		return
+	}
+
+	if err := File(&item, match.Tags); err != nil {
+		item.Reason = err.Error()
+		if item.Status == db.InboxFiled {
+			w.save(&item, "filed")
+			return
+		}
+		item.Status = db.InboxPending
+		w.save(&item, "queued")
+		return
+	}
+	w.save(&item, "filed")
+}
+
+// destination returns the product folder a match should be filed into
+func (w *Watcher) destination(match Match) (string, error) {
+	if match.Product != "" {
+		return match.Product, nil
+	}
+	return Destination(w.Home, w.Template, organize.Fields{
+		Title:   match.Title,
+		Shop:    match.ShopName,
+		Version: match.Version,
+		Tags:    match.Tags,
+	})
+}
+
+func (w *Watcher) save(item *db.InboxItem, event string) {
+	if err := db.AddInboxItem(item); err != nil {
+		log.Printf("Failed to record inbox item %s: %v", item.Path, err)
+		return
+	}
+	if w.OnEvent != nil {
+		w.OnEvent(event, *item)
+	}
+}
+
+// Destination renders the folder template for a download under home
+func Destination(home string, template string, fields organize.Fields) (string, error) {
+	rel, err := organize.Render(template, fields)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, rel), nil
+}
+
+// Identify works out which product a download belongs to. A download whose
+// name matches a product already in the library is a confident match. A Booth
+// search hit is confident only if its item ID appears in the file name or
+// its title closely matches the name, and the shop could be determined.
+// Anything else is filled in from the first hit and left for review.
+func Identify(path string) Match {
+	base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	title := booth.ExtractSearchQuery(base)
+	if title == "" {
+		title = base
+	}
+
+	match := Match{Title: title, Version: organize.ExtractVersion(base)}
+
+	for _, name := range []string{base, title} {
+		info, err := db.FindProductByName(name)
+		if err != nil || info == nil {
+			continue
+		}
+		match.Product = info.Path
+		match.ShopName = info.ShopName
+		match.Url = info.Url
+		match.ImageUrl = info.ImageUrl
+		match.Tags = info.Tags
+		match.Confident = true
+		match.Reason = "matched existing product by name"
+		return match
+	}
+
+	page, err := booth.FetchSearchPage(title)
+	if err != nil {
+		match.Reason = fmt.Sprintf("no Booth match: %v", err)
+		return match
+	}
+	cards := booth.ParseSearchResults(page)
+	if len(cards) == 0 {
+		match.Reason = "no Booth match"
+		return match
+	}
+
+	card, confirmed := confirmCard(base, title, cards)
+	match.Url = card.URL
+	match.ImageUrl = card.ImageURL
+	// Prefer the shop's display name; fall back to its subdomain
+	match.ShopName = card.Shop
+	if match.ShopName == "" {
+		match.ShopName = card.ShopID
+	}
+	switch {
+	case confirmed == "":
+		match.Reason = "Booth match not confirmed by the file name"
+	case match.ShopName == "":
+		match.Reason = "Booth match without shop name"
+	default:
+		match.Confident = true
+		match.Reason = "matched Booth search result by " + confirmed
+	}
+	return match
+}
+
+// itemIDPattern finds numbers in a file name that may be Booth item IDs
+var itemIDPattern = regexp.MustCompile(`\d{4,}`)
+
+// confirmCard picks the search hit a download belongs to and says what
+// confirms it: "item ID" if the hit's ID is in the file name, "title" if its
+// title closely matches, or "" if nothing does, in which case the first hit
+// is returned as a suggestion.
+func confirmCard(base string, title string, cards []booth.ItemCard) (booth.ItemCard, string) {
+	ids := make(map[string]bool)
+	for _, id := range itemIDPattern.FindAllString(base, -1) {
+		ids[id] = true
+	}
+	for _, card := range cards {
+		if card.ID != "" && ids[card.ID] {
+			return card, "item ID"
+		}
+	}
+	for _, card := range cards {
+		if titleMatches(title, card.Title) {
+			return card, "title"
+		}
+	}
+	return cards[0], ""
+}
+
+// titleMatches reports whether a name and a Booth title are close: equal
+// once case, spacing and punctuation are ignored, or one contained in the
+// other and at least two thirds its length
+func titleMatches(name string, title string) bool {
+	a, b := []rune(normalizeTitle(name)), []rune(normalizeTitle(booth.ExtractSearchQuery(title)))
+	if len(a) == 0 || len(b) == 0 {
+		return false
+	}
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	return strings.Contains(string(b), string(a)) && len(a)*3 >= len(b)*2
+}
+
+// normalizeTitle lowercases s and keeps only letters and digits
+func normalizeTitle(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, s)
+}
+
+// File moves an inbox item into its destination folder and registers the
+// folder as a product with the item's metadata. Once the file is moved the
+// item is marked as filed and its Path updated to the new location, even if
+// registering the product fails afterwards.
+func File(item *db.InboxItem, tags []string) error {
+	if item.Destination == "" {
+		return fmt.Errorf("no destination for %s", item.Path)
+	}
+
+	existing, err := db.GetProductInfo(item.Destination)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		// Check before moving anything that the folder can be registered
+		parent, err := db.GetParentProductInfo(item.Destination)
+		if err != nil {
+			return err
+		}
+		if parent != nil {
+			return fmt.Errorf("destination is inside existing product %s", parent.Path)
+		}
+	}
+
+	// Folders created above the product group products (e.g. by shop) and
+	// must not be registered as products themselves
+	var groups []string
+	for dir := filepath.Dir(item.Destination); dir != filepath.Dir(dir); dir = filepath.Dir(dir) {
+		if _, err := os.Stat(dir); err == nil {
+			break
+		}
+		groups = append(groups, dir)
+	}
+	if err := os.MkdirAll(item.Destination, 0755); err != nil {
+		return err
+	}
+	if err := db.AddInboxGroupFolders(groups); err != nil {
+		return err
+	}
+
+	target := fsutil.UniquePath(filepath.Join(item.Destination, filepath.Base(item.Path)))
+	if err := fsutil.Move(item.Path, target); err != nil {
+		return err
+	}
+	// The file has moved, so the item follows it even if registering fails
+	from := item.Path
+	item.Path = target
+	item.Status = db.InboxFiled
+	if err := db.RecordMove(db.NewBatchID(), 0, from, target); err != nil {
+		return err
+	}
+
+	if existing == nil {
+		if err := db.RegisterProduct(item.Destination, filepath.Base(item.Destination)); err != nil {
+			return err
+		}
+		if err := db.UpdateProduct(item.Destination, item.Url, item.ImageUrl, item.ShopName, tags); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isDownload(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, e := range Extensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
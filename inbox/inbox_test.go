@@ -0,0 +1,35 @@
+package inbox
+
+import (
+	"aslm/booth"
+	"testing"
+)
+
+func TestConfirmCard(t *testing.T) {
+	cards := []booth.ItemCard{
+		{ID: "1111111", Title: "【3D衣装】Sailor One-piece", Shop: "A"},
+		{ID: "2222222", Title: "Cat Ear Hoodie", Shop: "B"},
+		{ID: "3333333", Title: "Hoodie Set for 5 avatars (Manuka / Karin / Rusk / Shinano / Moe)", Shop: "C"},
+	}
+	cases := []struct {
+		base    string
+		wantID  string
+		confirm string
+	}{
+		{"2222222_hoodie_v1.2", "2222222", "item ID"},
+		{"3333333", "3333333", "item ID"},
+		{"Cat_Ear_Hoodie_v1.0", "2222222", "title"},
+		{"cat-ear hoodie", "2222222", "title"},
+		{"Sailor One-piece ver2", "1111111", "title"},
+		// The first hit is only a suggestion when nothing confirms it
+		{"Hoodie", "1111111", ""},
+		{"Manuka outfit 20240101", "1111111", ""},
+	}
+	for _, tc := range cases {
+		title := booth.ExtractSearchQuery(tc.base)
+		card, confirm := confirmCard(tc.base, title, cards)
+		if card.ID != tc.wantID || confirm != tc.confirm {
+			t.Errorf("confirmCard(%q) = %s by %q, want %s by %q", tc.base, card.ID, confirm, tc.wantID, tc.confirm)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package library
+
+import (
+	"aslm/config"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// MarkerFile is written to the top of every online root so the root can be
+// recognized after its path changes
+const MarkerFile = ".aslm-root"
+
+type marker struct {
+	ID    string `json:"id"`
+	Label string `json:"label"`
+}
+
+// ReadMarker returns the root ID recorded in dir, or "" if there is none
+func ReadMarker(dir string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(dir, MarkerFile))
+	if os.IsNotExist(err) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	var m marker
+	if err := json.Unmarshal(data, &m); err != nil {
+		return "", err
+	}
+	return m.ID, nil
+}
+
+// WriteMarker records root's ID in its folder unless a marker already exists,
+// and returns the ID found in the marker
+func WriteMarker(root config.Root) (string, error) {
+	id, err := ReadMarker(root.Path)
+	if err != nil || id != "" {
+		return id, err
+	}
+	data, err := json.MarshalIndent(marker{ID: root.ID, Label: root.Label}, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filepath.Join(root.Path, MarkerFile), data, 0644); err != nil {
+		return "", err
+	}
+	return root.ID, nil
+}
+
+// Locate looks for a missing root on other drive letters, e.g. a USB drive
+// that was D: and is now E:. It returns the new path, or "" if the root's
+// marker isn't found anywhere. Only drive letters are searched, so on other
+// platforms a moved mount point must be updated by the user.
+func Locate(root config.Root) string {
+	if runtime.GOOS != "windows" {
+		return ""
+	}
+	volume := filepath.VolumeName(root.Path)
+	if len(volume) != 2 || volume[1] != ':' {
+		return ""
+	}
+	rest := root.Path[len(volume):]
+
+	for letter := 'C'; letter <= 'Z'; letter++ {
+		candidate := string(letter) + ":" + rest
+		if strings.EqualFold(candidate[:2], volume) {
+			continue
+		}
+		if id, _ := ReadMarker(candidate); id == root.ID {
+			return candidate
+		}
+	}
+	return ""
+}
@@ -0,0 +1,104 @@
+package library
+
+import (
+	"aslm/config"
+	"aslm/db"
+	"aslm/fsutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Root states reported by State
+const (
+	StateOnline   = "online"
+	StateOffline  = "offline" // Folder missing, e.g. an unplugged drive or unmounted NAS
+	StateDisabled = "disabled"
+)
+
+// State reports whether a root can currently be used
+func State(root config.Root) string {
+	if !root.Enabled {
+		return StateDisabled
+	}
+	info, err := os.Stat(root.Path)
+	if err != nil || !info.IsDir() {
+		return StateOffline
+	}
+	return StateOnline
+}
+
+// Online returns the enabled roots whose folder currently exists
+func Online(roots []config.Root) []config.Root {
+	var online []config.Root
+	for _, r := range roots {
+		if State(r) == StateOnline {
+			online = append(online, r)
+		}
+	}
+	return online
+}
+
+// RootOf returns the deepest root containing path, or nil
+func RootOf(roots []config.Root, path string) *config.Root {
+	var found *config.Root
+	for i := range roots {
+		r := &roots[i]
+		if !fsutil.IsWithin(filepath.Clean(r.Path), filepath.Clean(path)) {
+			continue
+		}
+		if found == nil || len(r.Path) > len(found.Path) {
+			found = r
+		}
+	}
+	return found
+}
+
+// Allows reports whether the folder at path, inside root, should be
+// registered as a product according to the root's scan rules
+func Allows(root config.Root, path string) bool {
+	if !root.Enabled {
+		return false
+	}
+	dir := filepath.Clean(root.Path)
+	if !fsutil.IsWithin(dir, path) {
+		return false
+	}
+	rel, err := filepath.Rel(dir, filepath.Clean(path))
+	if err != nil || rel == "." {
+		return false
+	}
+
+	parts := strings.Split(rel, string(filepath.Separator))
+	if root.Scan.MaxDepth > 0 && len(parts) > root.Scan.MaxDepth {
+		return false
+	}
+	for _, part := range parts {
+		if matchAny(root.Scan.Exclude, part) {
+			return false
+		}
+	}
+	if len(root.Scan.Include) > 0 {
+		return matchAny(root.Scan.Include, parts[len(parts)-1])
+	}
+	return true
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// IsGroupFolder reports whether path is a folder the inbox created to group
+// the products it files, such as the shop folder of "{shop}/{title}". Group
+// folders are never registered as products, since downloads are filed below
+// them. Folders the user created are never group folders, so libraries
+// without an inbox keep one product per folder.
+func IsGroupFolder(path string) bool {
+	group, err := db.IsInboxGroupFolder(filepath.Clean(path))
+	return err == nil && group
+}
@@ -0,0 +1,75 @@
+package library
+
+import (
+	"aslm/config"
+	"aslm/db"
+	"io/fs"
+	"path/filepath"
+)
+
+// ScanResult counts what Scan found in a root
+type ScanResult struct {
+	Root       string `json:"root"`
+	Registered int    `json:"registered"` // Newly registered products
+	Existing   int    `json:"existing"`   // Products that were already registered
+}
+
+// Scan walks root and registers every folder its scan rules allow as a
+// product. Folders inside a product are not descended into, since a product
+// can't contain another, and folders the inbox created to group products are
+// descended into without being registered.
+func Scan(root config.Root) (*ScanResult, error) {
+	result := &ScanResult{Root: root.Path}
+	if State(root) != StateOnline {
+		return result, nil
+	}
+
+	err := filepath.WalkDir(root.Path, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Skip unreadable folders instead of aborting the whole scan
+			return nil
+		}
+		if !d.IsDir() || path == root.Path {
+			return nil
+		}
+		if root.Scan.MaxDepth > 0 && depth(root.Path, path) > root.Scan.MaxDepth {
+			return filepath.SkipDir
+		}
+		if matchAny(root.Scan.Exclude, d.Name()) {
+			return filepath.SkipDir
+		}
+		if !Allows(root, path) || IsGroupFolder(path) {
+			return nil
+		}
+
+		info, err := db.GetProductInfo(path)
+		if err != nil {
+			return err
+		}
+		if info != nil {
+			result.Existing++
+			return filepath.SkipDir
+		}
+		if err := db.RegisterProduct(path, d.Name()); err != nil {
+			// Inside an already registered product folder
+			return filepath.SkipDir
+		}
+		result.Registered++
+		return filepath.SkipDir
+	})
+	return result, err
+}
+
+func depth(root string, path string) int {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return 0
+	}
+	n := 1
+	for _, r := range rel {
+		if r == filepath.Separator {
+			n++
+		}
+	}
+	return n
+}
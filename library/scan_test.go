@@ -0,0 +1,104 @@
+package library
+
+import (
+	"aslm/config"
+	"aslm/db"
+	"aslm/inbox"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func openTestDB(t *testing.T) {
+	t.Helper()
+	config.SetDataDir(t.TempDir())
+	if err := db.InitDB(); err != nil {
+		t.Fatalf("InitDB: %v", err)
+	}
+	t.Cleanup(func() { db.DB.Close() })
+}
+
+func mkdirs(t *testing.T, paths ...string) {
+	t.Helper()
+	for _, p := range paths {
+		if err := os.MkdirAll(p, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func isProduct(t *testing.T, path string) bool {
+	t.Helper()
+	info, err := db.GetProductInfo(path)
+	if err != nil {
+		t.Fatalf("GetProductInfo: %v", err)
+	}
+	return info != nil
+}
+
+func TestScanFlatRootWithoutInbox(t *testing.T) {
+	openTestDB(t)
+	home := t.TempDir()
+	hat := filepath.Join(home, "Hat")
+	outfit := filepath.Join(home, "Outfit")
+	mkdirs(t, filepath.Join(hat, "Textures"), outfit)
+
+	result, err := Scan(config.Root{ID: "home", Path: home, Enabled: true})
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if result.Registered != 2 {
+		t.Errorf("registered %d products, want 2", result.Registered)
+	}
+	for _, path := range []string{hat, outfit} {
+		if IsGroupFolder(path) {
+			t.Errorf("%s is a group folder", path)
+		}
+		if !isProduct(t, path) {
+			t.Errorf("%s is not registered", path)
+		}
+	}
+	if isProduct(t, filepath.Join(hat, "Textures")) {
+		t.Error("a product's subfolder is registered")
+	}
+}
+
+func TestScanSkipsFoldersCreatedByInbox(t *testing.T) {
+	openTestDB(t)
+	home := t.TempDir()
+	download := filepath.Join(t.TempDir(), "hat.zip")
+	if err := os.WriteFile(download, []byte("zip"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	shop := filepath.Join(home, "Shop")
+	filed := filepath.Join(shop, "Hat")
+	item := &db.InboxItem{Path: download, Destination: filed, Status: db.InboxPending}
+	if err := inbox.File(item, nil); err != nil {
+		t.Fatalf("File: %v", err)
+	}
+	// Made by the user next to the inbox's folders
+	manual := filepath.Join(home, "Outfit")
+	mkdirs(t, filepath.Join(manual, "Textures"))
+
+	result, err := Scan(config.Root{ID: "home", Path: home, Enabled: true})
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if result.Registered != 1 || result.Existing != 1 {
+		t.Errorf("result = %+v, want 1 registered and 1 existing", result)
+	}
+	if !IsGroupFolder(shop) {
+		t.Errorf("%s is not a group folder", shop)
+	}
+	if isProduct(t, shop) {
+		t.Error("the inbox's shop folder is registered")
+	}
+	for _, path := range []string{filed, manual} {
+		if !isProduct(t, path) {
+			t.Errorf("%s is not registered", path)
+		}
+	}
+	if IsGroupFolder(home) {
+		t.Error("the existing root is recorded as a group folder")
+	}
+}
@@ -0,0 +1,63 @@
+package library
+
+import (
+	"aslm/config"
+	"aslm/db"
+	"aslm/organize"
+	"fmt"
+)
+
+// Sync records the current state of every root in the database, which also
+// resolves product paths against the roots, and returns the states in the
+// order of cfg.Roots. A missing root found on another drive letter is moved
+// there and the config saved. Online roots get a marker file so they can be
+// found again. When a root moved, the reorganize journals follow it as well.
+// Relocations, marker problems and products that couldn't follow their root
+// are returned as warnings for the caller to show.
+func Sync(cfg *config.Config) ([]string, []string, error) {
+	var warnings []string
+	states := make([]string, len(cfg.Roots))
+	rootStates := make([]db.RootState, len(cfg.Roots))
+	relocated := false
+	for i := range cfg.Roots {
+		r := &cfg.Roots[i]
+		states[i] = State(*r)
+
+		if states[i] == StateOffline {
+			if path := Locate(*r); path != "" {
+				warnings = append(warnings, fmt.Sprintf("library root %s moved: %s -> %s", r.Label, r.Path, path))
+				r.Path = path
+				states[i] = StateOnline
+				relocated = true
+			}
+		}
+		if states[i] == StateOnline {
+			if id, err := WriteMarker(*r); err != nil {
+				warnings = append(warnings, fmt.Sprintf("failed to write marker for library root %s: %v", r.Label, err))
+			} else if id != r.ID {
+				warnings = append(warnings, fmt.Sprintf("library root %s contains the marker of another root (%s)", r.Label, id))
+			}
+		}
+
+		rootStates[i] = db.RootState{ID: r.ID, Path: r.Path, Online: states[i] == StateOnline}
+	}
+
+	if relocated {
+		if err := config.SaveConfig(cfg); err != nil {
+			return nil, warnings, err
+		}
+	}
+	synced, err := db.SyncRoots(rootStates)
+	if err != nil {
+		return nil, warnings, fmt.Errorf("failed to update library roots: %w", err)
+	}
+	for _, c := range synced.Collisions {
+		warnings = append(warnings, fmt.Sprintf("product %s can't follow its root to %s: another product is registered there", c.Path, c.NewPath))
+	}
+	for _, m := range synced.Moved {
+		if err := organize.RebaseJournals(organize.JournalDir(), m.From, m.To); err != nil {
+			warnings = append(warnings, fmt.Sprintf("failed to update reorganize journals for %s: %v", m.To, err))
+		}
+	}
+	return states, warnings, nil
+}
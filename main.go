@@ -1,7 +1,10 @@
 package main
 
 import (
+	"aslm/cli"
+	"aslm/config"
 	"embed"
+	"os"
 
 	"github.com/wailsapp/wails/v2"
 	"github.com/wailsapp/wails/v2/pkg/options"
@@ -12,6 +15,14 @@ import (
 var assets embed.FS
 
 func main() {
+	// --data-dir and --portable must be applied before anything reads the config
+	args := config.ApplyArgs(os.Args[1:])
+
+	// Subcommands run headless, e.g. "aslm scan" from cron
+	if len(args) > 0 && cli.IsCommand(args[0]) {
+		os.Exit(cli.Run(args))
+	}
+
 	// Create an instance of the app structure
 	app := NewApp()
 
@@ -25,6 +36,7 @@ func main() {
 		},
 		BackgroundColour: &options.RGBA{R: 27, G: 38, B: 54, A: 1},
 		OnStartup:        app.startup,
+		OnShutdown:       app.shutdown,
 		Bind: []interface{}{
 			app,
 		},
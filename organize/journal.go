@@ -0,0 +1,246 @@
+package organize
+
+import (
+	"aslm/config"
+	"aslm/db"
+	"aslm/fsutil"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Journal records the moves of an executed reorganize so it can be undone
+type Journal struct {
+	ID        string    `json:"id"`
+	Root      string    `json:"root"`
+	Template  string    `json:"template"`
+	CreatedAt time.Time `json:"createdAt"`
+	Moves     []Move    `json:"moves"`
+	Undone    bool      `json:"undone"`
+}
+
+// Execute performs the moves of a plan (skipping conflicts) and updates the
+// database. The journal is written to journalDir before anything is moved,
+// so even an interrupted reorganize can be undone. If a move or the database
+// update fails, every move made so far is rolled back.
+func Execute(plan *Plan, journalDir string) (*Journal, error) {
+	journal := &Journal{
+		ID:        time.Now().Format("20060102-150405.000"),
+		Root:      plan.Root,
+		Template:  plan.Template,
+		CreatedAt: time.Now(),
+	}
+	for _, m := range plan.Moves {
+		if m.Conflict == "" {
+			journal.Moves = append(journal.Moves, m)
+		}
+	}
+	if len(journal.Moves) == 0 {
+		return nil, fmt.Errorf("nothing to move")
+	}
+
+	if err := saveJournal(journalDir, journal); err != nil {
+		return nil, fmt.Errorf("failed to write undo journal: %w", err)
+	}
+
+	var done []Move
+	for _, m := range journal.Moves {
+		if err := moveFolder(m.From, m.To, journal.Root); err != nil {
+			rollback(done, journal.Root)
+			os.Remove(journalPath(journalDir, journal.ID))
+			return nil, fmt.Errorf("failed to move %s: %w", m.From, err)
+		}
+		done = append(done, m)
+	}
+
+	if err := db.MoveProductPaths(pathMoves(done, false)); err != nil {
+		rollback(done, journal.Root)
+		os.Remove(journalPath(journalDir, journal.ID))
+		return nil, fmt.Errorf("failed to update database: %w", err)
+	}
+
+	// Also journal the moves as one batch so App.Undo can revert the whole reorganize
+	batchID := db.NewBatchID()
+	for _, m := range done {
+		productID, err := db.GetProductID(m.To)
+		if err != nil {
+			return journal, err
+		}
+		if err := db.RecordMove(batchID, productID, m.From, m.To); err != nil {
+			return journal, err
+		}
+	}
+
+	return journal, nil
+}
+
+// Undo reverts a reorganize. Moves whose destination no longer exists
+// (e.g. the reorganize was interrupted before reaching them) are skipped.
+// If a move fails, the database still follows the folders moved back so
+// far, and Undo can be retried.
+func Undo(journalDir string, id string) error {
+	journal, err := LoadJournal(journalDir, id)
+	if err != nil {
+		return err
+	}
+	if journal.Undone {
+		return fmt.Errorf("reorganize %s was already undone", id)
+	}
+
+	var reverted []Move
+	var moveErr error
+	for i := len(journal.Moves) - 1; i >= 0; i-- {
+		m := journal.Moves[i]
+		if !exists(m.To) || exists(m.From) {
+			continue
+		}
+		if err := moveFolder(m.To, m.From, journal.Root); err != nil {
+			moveErr = fmt.Errorf("failed to move %s back: %w", m.To, err)
+			break
+		}
+		reverted = append(reverted, m)
+	}
+
+	if err := db.MoveProductPaths(pathMoves(reverted, true)); err != nil {
+		return fmt.Errorf("failed to update database: %w", err)
+	}
+	if moveErr != nil {
+		return moveErr
+	}
+
+	journal.Undone = true
+	return saveJournal(journalDir, journal)
+}
+
+// JournalDir is where reorganize journals are kept
+func JournalDir() string {
+	return filepath.Join(config.DataDir(), "journals")
+}
+
+// RebaseJournals rewrites the paths in every journal that lie at or below
+// the folder from to be below to, after a library root moved as a whole
+func RebaseJournals(journalDir string, from string, to string) error {
+	journals, err := ListJournals(journalDir)
+	if err != nil {
+		return err
+	}
+	for i := range journals {
+		journal := &journals[i]
+		changed := false
+		rebase := func(path *string) {
+			if rebased, ok := db.RebasePath(*path, from, to); ok {
+				*path = rebased
+				changed = true
+			}
+		}
+		rebase(&journal.Root)
+		for j := range journal.Moves {
+			rebase(&journal.Moves[j].From)
+			rebase(&journal.Moves[j].To)
+		}
+		if changed {
+			if err := saveJournal(journalDir, journal); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// LoadJournal reads a single journal by ID
+func LoadJournal(journalDir string, id string) (*Journal, error) {
+	data, err := os.ReadFile(journalPath(journalDir, id))
+	if err != nil {
+		return nil, err
+	}
+	var journal Journal
+	if err := json.Unmarshal(data, &journal); err != nil {
+		return nil, err
+	}
+	return &journal, nil
+}
+
+// ListJournals returns all reorganize journals, newest first
+func ListJournals(journalDir string) ([]Journal, error) {
+	entries, err := os.ReadDir(journalDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var journals []Journal
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), ".json")
+		if !ok || !strings.HasPrefix(id, "reorganize-") {
+			continue
+		}
+		journal, err := LoadJournal(journalDir, strings.TrimPrefix(id, "reorganize-"))
+		if err != nil {
+			continue
+		}
+		journals = append(journals, *journal)
+	}
+
+	sort.Slice(journals, func(i, j int) bool { return journals[i].ID > journals[j].ID })
+	return journals, nil
+}
+
+func moveFolder(from string, to string, root string) error {
+	if err := os.MkdirAll(filepath.Dir(to), 0755); err != nil {
+		return err
+	}
+	if err := fsutil.Move(from, to); err != nil {
+		return err
+	}
+	removeEmptyParents(filepath.Dir(from), root)
+	return nil
+}
+
+// rollback moves already-moved folders back, newest first
+func rollback(done []Move, root string) {
+	for i := len(done) - 1; i >= 0; i-- {
+		moveFolder(done[i].To, done[i].From, root)
+	}
+}
+
+// removeEmptyParents deletes dir and its parents up to (not including) root while they are empty
+func removeEmptyParents(dir string, root string) {
+	for dir != root && fsutil.IsWithin(root, dir) {
+		if err := os.Remove(dir); err != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+func pathMoves(moves []Move, reverse bool) []db.PathMove {
+	result := make([]db.PathMove, 0, len(moves))
+	for _, m := range moves {
+		if reverse {
+			result = append(result, db.PathMove{From: m.To, To: m.From})
+		} else {
+			result = append(result, db.PathMove{From: m.From, To: m.To})
+		}
+	}
+	return result
+}
+
+func journalPath(journalDir string, id string) string {
+	return filepath.Join(journalDir, "reorganize-"+id+".json")
+}
+
+func saveJournal(journalDir string, journal *Journal) error {
+	if err := os.MkdirAll(journalDir, 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(journal, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(journalPath(journalDir, journal.ID), data, 0644)
+}
@@ -0,0 +1,155 @@
+package organize
+
+import (
+	"aslm/booth"
+	"aslm/db"
+	"aslm/fsutil"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+)
+
+// Move is a single product folder move in a plan
+type Move struct {
+	Product  string `json:"product"` // Product name
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Conflict string `json:"conflict,omitempty"` // Why the move can't be done; such moves are skipped
+}
+
+// Plan is the dry-run result of a reorganize
+type Plan struct {
+	Root      string `json:"root"`
+	Template  string `json:"template"`
+	Moves     []Move `json:"moves"`
+	Unchanged int    `json:"unchanged"`
+	Conflicts int    `json:"conflicts"`
+}
+
+// ErrStalePlan is returned when a previewed plan no longer matches the library
+var ErrStalePlan = errors.New("the library changed since the reorganize was previewed; preview it again")
+
+// Matches reports whether two plans make exactly the same moves
+func (p *Plan) Matches(other *Plan) bool {
+	return p.Root == other.Root && p.Template == other.Template && slices.Equal(p.Moves, other.Moves)
+}
+
+// FieldsFor builds the template fields for a product from its DB metadata
+func FieldsFor(p db.ProductInfo) Fields {
+	name := p.Name
+	if name == "" {
+		name = filepath.Base(p.Path)
+	}
+	title := booth.ExtractSearchQuery(name)
+	if title == "" {
+		title = name
+	}
+	return Fields{
+		Title:   title,
+		Shop:    p.ShopName,
+		Version: ExtractVersion(name),
+		Tags:    p.Tags,
+	}
+}
+
+// PlanReorganize computes where every product folder under root would go
+// according to template. Nothing is moved; see Execute.
+func PlanReorganize(root string, template string, products []db.ProductInfo) (*Plan, error) {
+	if err := Validate(template); err != nil {
+		return nil, err
+	}
+
+	root = filepath.Clean(root)
+	plan := &Plan{Root: root, Template: template}
+
+	sort.Slice(products, func(i, j int) bool { return products[i].Path < products[j].Path })
+
+	claimed := make(map[string]bool)
+	var finals []string
+
+	for _, p := range products {
+		from := filepath.Clean(p.Path)
+		if from == root || !fsutil.IsWithin(root, from) {
+			continue
+		}
+		if info, err := os.Stat(from); err != nil || !info.IsDir() {
+			continue
+		}
+
+		rel, err := Render(template, FieldsFor(p))
+		if err != nil {
+			return nil, err
+		}
+		to := filepath.Join(root, rel)
+
+		if to == from {
+			plan.Unchanged++
+			claimed[to] = true
+			finals = append(finals, from)
+			continue
+		}
+
+		move := Move{Product: p.Name, From: from, To: uniqueTarget(to, claimed)}
+		switch {
+		case fsutil.IsWithin(from, move.To):
+			move.Conflict = "destination is inside the product folder"
+		case exists(move.To):
+			move.Conflict = "destination already exists"
+		}
+
+		if move.Conflict == "" {
+			claimed[move.To] = true
+			finals = append(finals, move.To)
+		} else {
+			finals = append(finals, from)
+		}
+		plan.Moves = append(plan.Moves, move)
+	}
+
+	// Products may not end up nested inside each other
+	for i := range plan.Moves {
+		m := &plan.Moves[i]
+		if m.Conflict != "" {
+			continue
+		}
+		for _, other := range finals {
+			if other == m.To {
+				continue
+			}
+			if fsutil.IsWithin(other, m.To) {
+				m.Conflict = "destination is inside product " + other
+				break
+			}
+			if fsutil.IsWithin(m.To, other) {
+				m.Conflict = "destination would contain product " + other
+				break
+			}
+		}
+	}
+
+	for _, m := range plan.Moves {
+		if m.Conflict != "" {
+			plan.Conflicts++
+		}
+	}
+
+	return plan, nil
+}
+
+// uniqueTarget returns to, or "to (2)", "to (3)", ... if another product
+// in the plan already claimed it
+func uniqueTarget(to string, claimed map[string]bool) string {
+	candidate := to
+	for i := 2; claimed[candidate]; i++ {
+		candidate = fmt.Sprintf("%s (%d)", to, i)
+	}
+	return candidate
+}
+
+func exists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
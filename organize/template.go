@@ -0,0 +1,118 @@
+package organize
+
+import (
+	"aslm/fsutil"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// UnsortedFolder is used for a path segment whose placeholders all rendered empty
+const UnsortedFolder = "Unsorted"
+
+// Fields are the values available to a folder template
+type Fields struct {
+	Title   string
+	Shop    string
+	Version string
+	Tags    []string
+}
+
+var (
+	placeholderRe   = regexp.MustCompile(`\{([a-z]+)(?::([^{}]+))?\}`)
+	emptyBracketsRe = regexp.MustCompile(`\(\s*\)|\[\s*\]|【\s*】|（\s*）`)
+	spacesRe        = regexp.MustCompile(`\s+`)
+	versionRe       = regexp.MustCompile(`(?i)(?:^|[^a-z])v(?:er)?\.?\s?(\d+(?:\.\d+)*)`)
+)
+
+// Validate checks that a template only uses known placeholders
+func Validate(template string) error {
+	if strings.TrimSpace(template) == "" {
+		return fmt.Errorf("template is empty")
+	}
+	for _, m := range placeholderRe.FindAllStringSubmatch(template, -1) {
+		switch m[1] {
+		case "title", "shop", "version":
+			if m[2] != "" {
+				return fmt.Errorf("placeholder {%s} does not take an argument", m[1])
+			}
+		case "tag":
+			if m[2] == "" {
+				return fmt.Errorf("placeholder {tag} needs a group, e.g. {tag:category}")
+			}
+		default:
+			return fmt.Errorf("unknown placeholder {%s}", m[1])
+		}
+	}
+	return nil
+}
+
+// Render expands a template such as "{shop}/{title}" or
+// "{tag:category}/{shop}/{title} ({version})" into a relative folder path.
+// Each "/"-separated segment is sanitized into a valid folder name; brackets
+// left empty by a missing value are dropped, and a segment that renders
+// empty becomes UnsortedFolder.
+func Render(template string, fields Fields) (string, error) {
+	if err := Validate(template); err != nil {
+		return "", err
+	}
+
+	var segments []string
+	for _, segment := range strings.Split(filepath.ToSlash(template), "/") {
+		if strings.TrimSpace(segment) == "" {
+			continue
+		}
+
+		rendered := placeholderRe.ReplaceAllStringFunc(segment, func(m string) string {
+			parts := placeholderRe.FindStringSubmatch(m)
+			switch parts[1] {
+			case "title":
+				return fields.Title
+			case "shop":
+				return fields.Shop
+			case "version":
+				return fields.Version
+			case "tag":
+				return TagValue(fields.Tags, parts[2])
+			}
+			return ""
+		})
+
+		rendered = emptyBracketsRe.ReplaceAllString(rendered, "")
+		rendered = strings.TrimSpace(spacesRe.ReplaceAllString(rendered, " "))
+		if rendered == "" {
+			rendered = UnsortedFolder
+		}
+		segments = append(segments, fsutil.SanitizeName(rendered))
+	}
+
+	if len(segments) == 0 {
+		return "", fmt.Errorf("template renders to an empty path")
+	}
+	return filepath.Join(segments...), nil
+}
+
+// TagValue returns the value of the first tag in the given group.
+// A tag belongs to group "category" if it is written as "category:value"
+// or "category/value".
+func TagValue(tags []string, group string) string {
+	for _, tag := range tags {
+		for _, sep := range []string{":", "/"} {
+			if value, ok := strings.CutPrefix(tag, group+sep); ok && value != "" {
+				return value
+			}
+		}
+	}
+	return ""
+}
+
+// ExtractVersion returns a version such as "v1.2" found in a file or folder name,
+// or "" if there is none
+func ExtractVersion(name string) string {
+	m := versionRe.FindStringSubmatch(name)
+	if m == nil {
+		return ""
+	}
+	return "v" + m[1]
+}
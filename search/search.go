@@ -0,0 +1,242 @@
+package search
+
+import (
+	"aslm/ai"
+	"aslm/booth"
+	"aslm/db"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+const (
+	// embedBatchSize is how many products are embedded per request
+	embedBatchSize = 50
+	// candidates is how many results each ranking contributes before fusion
+	candidates = 50
+	// rrfK dampens the weight of top ranks in reciprocal rank fusion
+	rrfK = 60
+	// minSimilarity drops vector matches that are clearly unrelated
+	minSimilarity = 0.3
+)
+
+// Hit is one search result. Either rank is 0 if that ranking didn't find the product.
+type Hit struct {
+	Product    db.ProductInfo `json:"product"`
+	Score      float64        `json:"score"`
+	TextRank   int            `json:"textRank"`
+	VectorRank int            `json:"vectorRank"`
+	Similarity float64        `json:"similarity"` // Cosine similarity to the query, if embedded
+}
+
+// DocumentText is the text a product is embedded from
+func DocumentText(d db.SearchDocument) string {
+	parts := []string{d.Name}
+	if title := booth.ExtractSearchQuery(d.Name); title != "" && title != d.Name {
+		parts = append(parts, title)
+	}
+	if d.ShopName != "" {
+		parts = append(parts, "Shop: "+d.ShopName)
+	}
+	if d.Tags != "" {
+		parts = append(parts, "Tags: "+d.Tags)
+	}
+	if d.Description != "" {
+		parts = append(parts, d.Description)
+	}
+	return strings.Join(parts, "\n")
+}
+
+// Index rebuilds the full-text index and embeds every product whose text
+// changed since it was last embedded with this model. It returns the
+// number of products embedded. A nil embedder only rebuilds the text index.
+func Index(ctx context.Context, e ai.Embedder) (int, error) {
+	if err := db.RefreshTextIndex(); err != nil {
+		return 0, fmt.Errorf("failed to refresh text index: %w", err)
+	}
+	if e == nil {
+		return 0, nil
+	}
+
+	docs, err := db.SearchDocuments()
+	if err != nil {
+		return 0, err
+	}
+	model := e.EmbeddingModel()
+	hashes, err := db.EmbeddingHashes(model)
+	if err != nil {
+		return 0, err
+	}
+
+	type pending struct {
+		path, text, hash string
+	}
+	var stale []pending
+	for _, d := range docs {
+		text := DocumentText(d)
+		sum := sha256.Sum256([]byte(text))
+		hash := hex.EncodeToString(sum[:])
+		if hashes[d.Path] != hash {
+			stale = append(stale, pending{d.Path, text, hash})
+		}
+	}
+
+	embedded := 0
+	for start := 0; start < len(stale); start += embedBatchSize {
+		batch := stale[start:min(start+embedBatchSize, len(stale))]
+		texts := make([]string, len(batch))
+		for i, p := range batch {
+			texts[i] = p.text
+		}
+
+		vectors, _, err := e.Embed(ctx, texts)
+		if err != nil {
+			return embedded, fmt.Errorf("failed to embed products: %w", err)
+		}
+		for i, p := range batch {
+			if err := db.SaveEmbedding(p.path, model, p.hash, normalize(vectors[i])); err != nil {
+				return embedded, err
+			}
+			embedded++
+		}
+	}
+
+	return embedded, nil
+}
+
+// Search finds products by combining full-text matches with embedding
+// similarity using reciprocal rank fusion, so a query like "cat-ear hoodie"
+// also finds "猫耳パーカー". With a nil embedder, or if the query can't be
+// embedded, only full-text search is used.
+func Search(ctx context.Context, e ai.Embedder, query string, limit int) ([]Hit, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, nil
+	}
+
+	hits := make(map[string]*Hit)
+	hit := func(path string) *Hit {
+		if h, ok := hits[path]; ok {
+			return h
+		}
+		h := &Hit{Product: db.ProductInfo{Path: path}}
+		hits[path] = h
+		return h
+	}
+
+	textPaths, err := db.TextSearch(query, candidates)
+	if err != nil {
+		return nil, err
+	}
+	for i, path := range textPaths {
+		h := hit(path)
+		h.TextRank = i + 1
+		h.Score += 1.0 / float64(rrfK+i+1)
+	}
+
+	if e != nil {
+		// The text ranking is still useful when the embedder is down or
+		// over budget, so a failed query embedding only drops the vector ranking
+		ranked, err := vectorSearch(ctx, e, query)
+		if err != nil {
+			fmt.Printf("Semantic search skipped: %v\n", err)
+		}
+		for i, r := range ranked {
+			h := hit(r.path)
+			h.VectorRank = i + 1
+			h.Similarity = r.similarity
+			h.Score += 1.0 / float64(rrfK+i+1)
+		}
+	}
+
+	results := make([]Hit, 0, len(hits))
+	for _, h := range hits {
+		info, err := db.GetProductInfo(h.Product.Path)
+		if err != nil {
+			return nil, err
+		}
+		if info == nil {
+			continue
+		}
+		h.Product = *info
+		results = append(results, *h)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Product.Path < results[j].Product.Path
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+type scored struct {
+	path       string
+	similarity float64
+}
+
+// vectorSearch returns the products most similar to the query, best first
+func vectorSearch(ctx context.Context, e ai.Embedder, query string) ([]scored, error) {
+	embeddings, err := db.LoadEmbeddings(e.EmbeddingModel())
+	if err != nil {
+		return nil, err
+	}
+	if len(embeddings) == 0 {
+		return nil, nil
+	}
+
+	vectors, _, err := e.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+	q := normalize(vectors[0])
+
+	ranked := make([]scored, 0, len(embeddings))
+	for _, emb := range embeddings {
+		if len(emb.Vector) != len(q) {
+			continue
+		}
+		if similarity := dot(q, emb.Vector); similarity >= minSimilarity {
+			ranked = append(ranked, scored{emb.Path, similarity})
+		}
+	}
+	sort.Slice(ranked, func(i, j int) bool { return ranked[i].similarity > ranked[j].similarity })
+
+	if len(ranked) > candidates {
+		ranked = ranked[:candidates]
+	}
+	return ranked, nil
+}
+
+// normalize scales v to unit length so cosine similarity is a dot product
+func normalize(v []float32) []float32 {
+	var sum float64
+	for _, f := range v {
+		sum += float64(f) * float64(f)
+	}
+	if sum == 0 {
+		return v
+	}
+	norm := float32(math.Sqrt(sum))
+	out := make([]float32, len(v))
+	for i, f := range v {
+		out[i] = f / norm
+	}
+	return out
+}
+
+func dot(a []float32, b []float32) float64 {
+	var sum float64
+	for i := range a {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum
+}
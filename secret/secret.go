@@ -0,0 +1,269 @@
+package secret
+
+import (
+	"aslm/config"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/zalando/go-keyring"
+)
+
+// Names of the stored secrets
+const (
+	GeminiAPIKey = "gemini-api-key"
+	AIAPIKey     = "ai-api-key" // Key for the OpenAI-compatible server
+	APIToken     = "api-token"  // Bearer token of the local REST API
+)
+
+// Storage backends reported by Backend
+const (
+	BackendKeyring = "keyring"
+	BackendFile    = "file"
+)
+
+// service is the keyring service name secrets are stored under
+const service = "aslm"
+
+var mu sync.Mutex
+
+// Get returns a secret, or "" if it isn't set. The OS keyring is tried
+// first, then the encrypted file fallback.
+func Get(name string) (string, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	value, err := keyring.Get(service, name)
+	if err == nil {
+		return value, nil
+	}
+
+	secrets, err := readFile()
+	if err != nil {
+		return "", err
+	}
+	return secrets[name], nil
+}
+
+// Set stores a secret in the OS keyring, or in the encrypted file if no
+// keyring is available (e.g. Linux without a Secret Service). An empty
+// value deletes the secret.
+func Set(name string, value string) error {
+	if value == "" {
+		return Delete(name)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if err := keyring.Set(service, name, value); err == nil {
+		// Don't leave an older copy in the file
+		return removeFromFile(name)
+	}
+
+	secrets, err := readFile()
+	if err != nil {
+		return err
+	}
+	secrets[name] = value
+	return writeFile(secrets)
+}
+
+// Delete removes a secret from both the keyring and the file
+func Delete(name string) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if err := keyring.Delete(service, name); err != nil && !errors.Is(err, keyring.ErrNotFound) {
+		fmt.Printf("Keyring unavailable, deleting from file only: %v\n", err)
+	}
+	return removeFromFile(name)
+}
+
+// Backend reports where a secret is stored: BackendKeyring, BackendFile, or "" if it isn't set
+func Backend(name string) string {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, err := keyring.Get(service, name); err == nil {
+		return BackendKeyring
+	}
+	if secrets, err := readFile(); err == nil && secrets[name] != "" {
+		return BackendFile
+	}
+	return ""
+}
+
+// Mask returns a form of a secret that is safe to show, e.g. "AIza…x9Qk"
+func Mask(value string) string {
+	runes := []rune(value)
+	if len(runes) <= 8 {
+		return "••••"
+	}
+	return string(runes[:4]) + "…" + string(runes[len(runes)-4:])
+}
+
+// The fallback file is encrypted with AES-GCM using a random key kept in a
+// separate file. Both are only readable by the user. This keeps keys out of
+// config.json (which gets shared and backed up) but, unlike the keyring,
+// doesn't protect against someone who can read the user's files: the key
+// sits next to secrets.enc in the data folder, so whoever copies the whole
+// folder can decrypt it. doctor warns about secrets stored this way.
+
+// FileSecrets returns the names of the secrets held in the fallback file
+// instead of the OS keyring
+func FileSecrets() ([]string, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	secrets, err := readFile()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(secrets))
+	for name := range secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func secretsPath() string {
+	return filepath.Join(config.DataDir(), "secrets.enc")
+}
+
+func keyPath() string {
+	return filepath.Join(config.DataDir(), "secrets.key")
+}
+
+func readFile() (map[string]string, error) {
+	secrets := make(map[string]string)
+
+	data, err := os.ReadFile(secretsPath())
+	if os.IsNotExist(err) {
+		return secrets, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	gcm, err := fileCipher(false)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, fmt.Errorf("secrets file is corrupted")
+	}
+	plain, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt secrets file: %w", err)
+	}
+
+	if err := json.Unmarshal(plain, &secrets); err != nil {
+		return nil, err
+	}
+	return secrets, nil
+}
+
+func writeFile(secrets map[string]string) error {
+	if len(secrets) == 0 {
+		err := os.Remove(secretsPath())
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	plain, err := json.Marshal(secrets)
+	if err != nil {
+		return err
+	}
+	gcm, err := fileCipher(true)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	tmp := secretsPath() + ".tmp"
+	if err := os.WriteFile(tmp, gcm.Seal(nonce, nonce, plain, nil), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, secretsPath())
+}
+
+func removeFromFile(name string) error {
+	secrets, err := readFile()
+	if err != nil {
+		return err
+	}
+	if _, ok := secrets[name]; !ok {
+		return nil
+	}
+	delete(secrets, name)
+	return writeFile(secrets)
+}
+
+// fileCipher loads the file key, creating it if create is set
+func fileCipher(create bool) (cipher.AEAD, error) {
+	key, err := os.ReadFile(keyPath())
+	if os.IsNotExist(err) && create {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(keyPath(), key, 0600); err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read secrets key: %w", err)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// MigrateConfig moves API keys still stored in plaintext in config.json into
+// the secret store and removes them from the config
+func MigrateConfig() error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	legacy := []struct {
+		name  string
+		value *string
+	}{
+		{GeminiAPIKey, &cfg.GeminiAPIKey},
+		{AIAPIKey, &cfg.AIAPIKey},
+	}
+
+	migrated := false
+	for _, l := range legacy {
+		if *l.value == "" {
+			continue
+		}
+		if err := Set(l.name, *l.value); err != nil {
+			return fmt.Errorf("failed to move %s to secure storage: %w", l.name, err)
+		}
+		*l.value = ""
+		migrated = true
+	}
+
+	if !migrated {
+		return nil
+	}
+	return config.SaveConfig(cfg)
+}
@@ -0,0 +1,266 @@
+// Package server is a local REST API over the asset library for tools such
+// as Unity editor scripts. It only listens on 127.0.0.1 and every request
+// except the OpenAPI document needs the bearer token.
+package server
+
+import (
+	"aslm/db"
+	"aslm/search"
+	"aslm/secret"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultPort is used when no port is configured
+const DefaultPort = 47811
+
+// maxBodyBytes limits request bodies
+const maxBodyBytes = 1 << 20
+
+//go:embed openapi.json
+var openAPI []byte
+
+// Product is a product as returned by the API
+type Product struct {
+	Path     string   `json:"path"`
+	Name     string   `json:"name"`
+	URL      string   `json:"url"`
+	ImageURL string   `json:"imageUrl"`
+	Shop     string   `json:"shop"`
+	Tags     []string `json:"tags"`
+	RootID   string   `json:"rootId,omitempty"`
+	RelPath  string   `json:"relPath,omitempty"`
+	Offline  bool     `json:"offline"`
+}
+
+// SearchHit is a search result as returned by the API
+type SearchHit struct {
+	Product    Product `json:"product"`
+	Score      float64 `json:"score"`
+	Similarity float64 `json:"similarity,omitempty"`
+}
+
+// SearchFunc runs a product search, e.g. App.SearchProducts
+type SearchFunc func(query string, limit int) ([]search.Hit, error)
+
+// Server serves the API
+type Server struct {
+	Port   int
+	Token  string
+	Search SearchFunc // Optional; full-text search only if nil
+
+	http *http.Server
+}
+
+// NewToken returns a random API token
+func NewToken() string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// LoadToken returns the saved API token, creating one on first use
+func LoadToken() (string, error) {
+	token, err := secret.Get(secret.APIToken)
+	if err != nil || token != "" {
+		return token, err
+	}
+	token = NewToken()
+	return token, secret.Set(secret.APIToken, token)
+}
+
+// Addr returns the address the server listens on
+func (s *Server) Addr() string {
+	port := s.Port
+	if port == 0 {
+		port = DefaultPort
+	}
+	return net.JoinHostPort("127.0.0.1", strconv.Itoa(port))
+}
+
+// Start listens on Addr and serves in the background until Stop
+func (s *Server) Start() error {
+	if s.Token == "" {
+		return fmt.Errorf("API token not set")
+	}
+	ln, err := net.Listen("tcp", s.Addr())
+	if err != nil {
+		return err
+	}
+	s.http = &http.Server{Handler: s.Handler(), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := s.http.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fmt.Printf("API server stopped: %v\n", err)
+		}
+	}()
+	return nil
+}
+
+// Stop shuts the server down, waiting briefly for running requests
+func (s *Server) Stop() error {
+	if s.http == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := s.http.Shutdown(ctx)
+	s.http = nil
+	return err
+}
+
+// Handler returns the API routes
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(openAPI)
+	})
+	mux.Handle("GET /api/v1/products", s.auth(s.listProducts))
+	mux.Handle("GET /api/v1/search", s.auth(s.searchProducts))
+	mux.Handle("GET /api/v1/product", s.auth(s.getProduct))
+	mux.Handle("PATCH /api/v1/product", s.auth(s.updateProduct))
+	return mux
+}
+
+// auth rejects requests without the bearer token
+func (s *Server) auth(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.Token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="aslm"`)
+			writeError(w, http.StatusUnauthorized, "missing or invalid token")
+			return
+		}
+		next(w, r)
+	})
+}
+
+// listProducts handles GET /products?q=&tag=&shop=&untagged=
+func (s *Server) listProducts(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	untagged, _ := strconv.ParseBool(q.Get("untagged"))
+	products, err := db.FindProducts(db.ProductQuery{
+		Text:     q.Get("q"),
+		Tags:     q["tag"],
+		Shop:     q.Get("shop"),
+		Untagged: untagged,
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	result := make([]Product, 0, len(products))
+	for _, p := range products {
+		result = append(result, toProduct(p))
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// searchProducts handles GET /search?q=&limit=
+func (s *Server) searchProducts(w http.ResponseWriter, r *http.Request) {
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		writeError(w, http.StatusBadRequest, "q is required")
+		return
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	var hits []search.Hit
+	var err error
+	if s.Search != nil {
+		hits, err = s.Search(query, limit)
+	} else {
+		hits, err = search.Search(r.Context(), nil, query, limit)
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	result := make([]SearchHit, 0, len(hits))
+	for _, h := range hits {
+		result = append(result, SearchHit{Product: toProduct(h.Product), Score: h.Score, Similarity: h.Similarity})
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// getProduct handles GET /product?path=
+func (s *Server) getProduct(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Query().Get("path")
+	info, err := db.GetProductInfo(path)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if info == nil {
+		writeError(w, http.StatusNotFound, "product not found: "+path)
+		return
+	}
+	writeJSON(w, http.StatusOK, toProduct(*info))
+}
+
+// updateProduct handles PATCH /product?path= with a db.ProductPatch body
+func (s *Server) updateProduct(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Query().Get("path")
+
+	var patch db.ProductPatch
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&patch); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid body: "+err.Error())
+		return
+	}
+
+	err := db.PatchProduct(path, patch)
+	switch {
+	case errors.Is(err, db.ErrNotFound):
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	case errors.Is(err, db.ErrConstraint):
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.getProduct(w, r)
+}
+
+func toProduct(p db.ProductInfo) Product {
+	tags := p.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	return Product{
+		Path:     p.Path,
+		Name:     p.Name,
+		URL:      p.Url,
+		ImageURL: p.ImageUrl,
+		Shop:     p.ShopName,
+		Tags:     tags,
+		RootID:   p.RootID,
+		RelPath:  p.RelPath,
+		Offline:  p.Offline,
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}